@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"property-managment-service/internal/apikey/repository"
+	"property-managment-service/internal/apikey/service"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/config"
+	"property-managment-service/lib/sl"
+	"property-managment-service/pkg/db"
+	"strings"
+)
+
+const usage = `usage:
+  api-keys create -name <name> -scopes <scope,scope>
+  api-keys list
+  api-keys revoke -id <id>
+
+scopes: %s
+`
+
+// api-keys управляет ключами доступа внутренних сервисов. Открытый ключ печатается
+// только при создании - сохранить его нужно сразу, восстановить из базы нельзя.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, usage, strings.Join(authz.KnownScopes, ", "))
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	log := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	psqlDB, err := db.NewPsqlDB(cfg)
+	if err != nil {
+		log.Error("failed to connect to postgresql", sl.Err(err))
+		os.Exit(1)
+	}
+	defer psqlDB.Close()
+
+	keys := service.NewApiKeyService(repository.NewApiKeyRepository(psqlDB), log)
+	ctx := context.Background()
+
+	var result interface{}
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "create":
+		fs := flag.NewFlagSet("create", flag.ExitOnError)
+		name := fs.String("name", "", "key owner, e.g. booking-service")
+		scopes := fs.String("scopes", "", "comma-separated scopes")
+		_ = fs.Parse(args)
+
+		key, raw, err := keys.Create(ctx, *name, splitScopes(*scopes))
+		if err != nil {
+			log.Error("failed to create api key", sl.Err(err))
+			os.Exit(1)
+		}
+		result = map[string]interface{}{"key": key, "secret": raw}
+	case "list":
+		list, err := keys.List(ctx)
+		if err != nil {
+			log.Error("failed to list api keys", sl.Err(err))
+			os.Exit(1)
+		}
+		result = list
+	case "revoke":
+		fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+		id := fs.Int64("id", 0, "key id")
+		_ = fs.Parse(args)
+
+		key, err := keys.Revoke(ctx, *id)
+		if err != nil {
+			log.Error("failed to revoke api key", slog.Int64("id", *id), sl.Err(err))
+			os.Exit(1)
+		}
+		result = key
+	default:
+		fmt.Fprintf(os.Stderr, usage, strings.Join(authz.KnownScopes, ", "))
+		os.Exit(2)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Error("failed to write result", sl.Err(err))
+		os.Exit(1)
+	}
+}
+
+func splitScopes(value string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"property-managment-service/internal/config"
+	"property-managment-service/internal/image/delivery/repository"
+	"property-managment-service/internal/image/gc"
+	"property-managment-service/internal/image/storage"
+	"property-managment-service/lib/sl"
+	"property-managment-service/pkg/db"
+)
+
+// image-gc сверяет хранилище изображений с таблицей properties_images и печатает отчёт в JSON.
+// По умолчанию ничего не удаляет; с флагом -fix исправляет найденные расхождения.
+func main() {
+	var fix bool
+	flag.BoolVar(&fix, "fix", false, "delete orphaned objects and rows with missing files")
+	gracePeriod := flag.Duration("grace-period", 0, "ignore objects younger than this (default from config)")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	log := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	if *gracePeriod == 0 {
+		*gracePeriod = cfg.Images.GC.GracePeriod
+	}
+
+	psqlDB, err := db.NewPsqlDB(cfg)
+	if err != nil {
+		log.Error("failed to connect to postgresql", sl.Err(err))
+		os.Exit(1)
+	}
+	defer psqlDB.Close()
+
+	ctx := context.Background()
+	imageStorage, err := storage.New(ctx, cfg.Storage)
+	if err != nil {
+		log.Error("failed to init image storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	collector := gc.NewCollector(repository.NewImageRepository(psqlDB), imageStorage, db.NewTransactionManager(psqlDB), *gracePeriod, log)
+	report, err := collector.Run(ctx, !fix)
+	if err != nil {
+		log.Error("image gc failed", sl.Err(err))
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Error("failed to write report", sl.Err(err))
+		os.Exit(1)
+	}
+}
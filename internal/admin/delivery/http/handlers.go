@@ -0,0 +1,235 @@
+package http
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"net/http"
+	"property-managment-service/internal/image/gc"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/lib/sl"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"strconv"
+	"time"
+)
+
+type ReviewModerator interface {
+	Moderate(ctx context.Context, id int64) (int64, error)
+}
+
+type PropertyFormService interface {
+	DeletePropertyForm(ctx context.Context, propertyID int64) error
+}
+
+type PropertyModerator interface {
+	GetByStatus(ctx context.Context, status string) ([]*models.Property, error)
+	Approve(ctx context.Context, id int64) (*models.Property, error)
+	Suspend(ctx context.Context, id int64, reason string) (*models.Property, error)
+}
+
+type AuditLog interface {
+	Find(ctx context.Context, filter *models.AuditFilter) (*models.AuditPage, error)
+}
+
+type ImageCollector interface {
+	Run(ctx context.Context, dryRun bool) (*gc.Report, error)
+}
+
+type adminHandlers struct {
+	reviewService       ReviewModerator
+	propertyFormService PropertyFormService
+	propertyModerator   PropertyModerator
+	collector           ImageCollector
+	auditLog            AuditLog
+	log                 *slog.Logger
+}
+
+func NewAdminHandlers(
+	reviewService ReviewModerator,
+	propertyFormService PropertyFormService,
+	propertyModerator PropertyModerator,
+	collector ImageCollector,
+	auditLog AuditLog,
+	log *slog.Logger,
+) AdminHandlers {
+	return &adminHandlers{
+		reviewService:       reviewService,
+		propertyFormService: propertyFormService,
+		propertyModerator:   propertyModerator,
+		collector:           collector,
+		auditLog:            auditLog,
+		log:                 log,
+	}
+}
+
+func (h *adminHandlers) ModerateReview() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling ModerateReview", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		deletedId, err := h.reviewService.Moderate(ctx, id)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, deletedId)
+	}
+}
+
+func (h *adminHandlers) BulkDeleteProperties() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling BulkDeleteProperties", slog.String("request_id", requestID))
+		r := &request.BulkDeletePropertiesRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		// Каждое объявление удаляется в своей транзакции: ошибка одного не откатывает остальные
+		deleted := []int64{}
+		failed := []int64{}
+		for _, id := range r.Ids {
+			if err := h.propertyFormService.DeletePropertyForm(ctx, id); err != nil {
+				h.log.Error("failed to delete property", slog.Int64("property id", id), sl.Err(err))
+				failed = append(failed, id)
+				continue
+			}
+			deleted = append(deleted, id)
+		}
+		return c.JSON(http.StatusOK, map[string][]int64{
+			"deleted": deleted,
+			"failed":  failed,
+		})
+	}
+}
+
+// GetProperties возвращает очередь модерации: по умолчанию объявления, ожидающие проверки
+func (h *adminHandlers) GetProperties() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetProperties", slog.String("request_id", requestID))
+		status := c.QueryParam("status")
+		switch status {
+		case "":
+			status = models.PropertyStatusPendingReview
+		case models.PropertyStatusDraft, models.PropertyStatusPendingReview,
+			models.PropertyStatusPublished, models.PropertyStatusSuspended:
+		default:
+			return httpErrors.NewBadRequestError("invalid status")
+		}
+
+		properties, err := h.propertyModerator.GetByStatus(ctx, status)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, properties)
+	}
+}
+
+func (h *adminHandlers) ApproveProperty() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling ApproveProperty", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		property, err := h.propertyModerator.Approve(ctx, id)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, property)
+	}
+}
+
+func (h *adminHandlers) SuspendProperty() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling SuspendProperty", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		r := &request.SuspendPropertyRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		property, err := h.propertyModerator.Suspend(ctx, id, r.Reason)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, property)
+	}
+}
+
+func (h *adminHandlers) RunImageGC() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling RunImageGC", slog.String("request_id", requestID))
+
+		// По умолчанию только отчёт, удаление - явным dryRun=false
+		dryRun := true
+		if v := c.QueryParam("dryRun"); v != "" {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return httpErrors.NewBadRequestError("invalid dryRun")
+			}
+			dryRun = parsed
+		}
+
+		report, err := h.collector.Run(ctx, dryRun)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, report)
+	}
+}
+
+func (h *adminHandlers) GetAuditLog() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetAuditLog", slog.String("request_id", requestID))
+		r := &request.AuditLogRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		filter := &models.AuditFilter{
+			Entity:   r.Entity,
+			EntityId: r.EntityId,
+			ActorId:  r.ActorId,
+			BeforeId: r.BeforeId,
+			Limit:    r.Limit,
+		}
+		// Формат уже проверен валидатором
+		if r.From != "" {
+			from, _ := time.Parse(time.RFC3339, r.From)
+			filter.From = &from
+		}
+		if r.To != "" {
+			to, _ := time.Parse(time.RFC3339, r.To)
+			filter.To = &to
+		}
+
+		page, err := h.auditLog.Find(ctx, filter)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, page)
+	}
+}
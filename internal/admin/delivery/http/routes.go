@@ -0,0 +1,29 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/middleware"
+)
+
+type AdminHandlers interface {
+	ModerateReview() echo.HandlerFunc
+	BulkDeleteProperties() echo.HandlerFunc
+	GetProperties() echo.HandlerFunc
+	ApproveProperty() echo.HandlerFunc
+	SuspendProperty() echo.HandlerFunc
+	RunImageGC() echo.HandlerFunc
+	GetAuditLog() echo.HandlerFunc
+}
+
+func MapAdminRoutes(adminGroup *echo.Group, h AdminHandlers, mw *middleware.MiddlewareManager) {
+	// Все маршруты группы доступны только администратору
+	adminGroup.Use(mw.AuthJWTMiddleware(), mw.RequireRole(authz.RoleAdmin))
+	adminGroup.DELETE("/reviews/:id", h.ModerateReview())
+	adminGroup.POST("/properties/bulk-delete", h.BulkDeleteProperties())
+	adminGroup.GET("/properties", h.GetProperties())
+	adminGroup.POST("/properties/:id/approve", h.ApproveProperty())
+	adminGroup.POST("/properties/:id/suspend", h.SuspendProperty())
+	adminGroup.POST("/images/gc", h.RunImageGC())
+	adminGroup.GET("/audit", h.GetAuditLog())
+}
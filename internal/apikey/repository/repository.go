@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"property-managment-service/internal/apikey/service"
+	"property-managment-service/internal/models"
+)
+
+type apiKeyRepository struct {
+	Db *sqlx.DB
+}
+
+func NewApiKeyRepository(db *sqlx.DB) service.ApiKeyRepository {
+	return &apiKeyRepository{Db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.ApiKey) (*models.ApiKey, error) {
+	const op = "apiKeyRepository.Create"
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes)
+			  VALUES ($1, $2, $3, $4) RETURNING *`
+	created := &models.ApiKey{}
+	if err := r.Db.QueryRowxContext(ctx, query, key.Name, key.Prefix, key.KeyHash, key.Scopes).StructScan(created); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return created, nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.ApiKey, error) {
+	const op = "apiKeyRepository.GetByHash"
+	query := `SELECT * FROM api_keys WHERE key_hash = $1`
+	key := &models.ApiKey{}
+	if err := r.Db.QueryRowxContext(ctx, query, hash).StructScan(key); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return key, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]models.ApiKey, error) {
+	const op = "apiKeyRepository.List"
+	query := `SELECT * FROM api_keys ORDER BY id`
+	keys := []models.ApiKey{}
+	if err := r.Db.SelectContext(ctx, &keys, query); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int64) (*models.ApiKey, error) {
+	const op = "apiKeyRepository.Revoke"
+	// Повторный отзыв не сдвигает дату первого
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1 RETURNING *`
+	key := &models.ApiKey{}
+	if err := r.Db.QueryRowxContext(ctx, query, id).StructScan(key); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return key, nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	const op = "apiKeyRepository.TouchLastUsed"
+	// Пишем не чаще раза в минуту, чтобы частые вызовы сервиса не превращались в поток UPDATE
+	query := `UPDATE api_keys SET last_used_at = now()
+			  WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')`
+	if _, err := r.Db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
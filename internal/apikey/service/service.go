@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/models"
+	"property-managment-service/lib/sl"
+	"property-managment-service/pkg/httpErrors"
+	"strings"
+)
+
+const (
+	// keyPrefix отличает ключи сервиса от других секретов, например при поиске утечек в логах
+	keyPrefix = "pms_"
+	// prefixLength - сколько первых символов ключа сохраняется открыто для опознания в списке
+	prefixLength = 12
+)
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, key *models.ApiKey) (*models.ApiKey, error)
+	GetByHash(ctx context.Context, hash string) (*models.ApiKey, error)
+	List(ctx context.Context) ([]models.ApiKey, error)
+	Revoke(ctx context.Context, id int64) (*models.ApiKey, error)
+	TouchLastUsed(ctx context.Context, id int64) error
+}
+
+type ApiKeyService struct {
+	repo ApiKeyRepository
+	log  *slog.Logger
+}
+
+func NewApiKeyService(repo ApiKeyRepository, log *slog.Logger) *ApiKeyService {
+	return &ApiKeyService{repo: repo, log: log}
+}
+
+// Create выпускает ключ. Открытый ключ возвращается только здесь - в базе остаётся его хэш
+func (s *ApiKeyService) Create(ctx context.Context, name string, scopes []string) (*models.ApiKey, string, error) {
+	const op = "ApiKeyService.Create"
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%s: name is required", op)
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%s: at least one scope is required", op)
+	}
+	for _, scope := range scopes {
+		if !isKnownScope(scope) {
+			return nil, "", fmt.Errorf("%s: unknown scope %q", op, scope)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	raw := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key, err := s.repo.Create(ctx, &models.ApiKey{
+		Name:    name,
+		Prefix:  raw[:prefixLength],
+		KeyHash: hashKey(raw),
+		Scopes:  scopes,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	return key, raw, nil
+}
+
+func (s *ApiKeyService) List(ctx context.Context) ([]models.ApiKey, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *ApiKeyService) Revoke(ctx context.Context, id int64) (*models.ApiKey, error) {
+	return s.repo.Revoke(ctx, id)
+}
+
+// Authenticate находит действующий ключ; неизвестный и отозванный ключи неразличимы для вызывающего
+func (s *ApiKeyService) Authenticate(ctx context.Context, raw string) (*models.ApiKey, error) {
+	const op = "ApiKeyService.Authenticate"
+	if !strings.HasPrefix(raw, keyPrefix) {
+		return nil, httpErrors.Unauthorized
+	}
+
+	key, err := s.repo.GetByHash(ctx, hashKey(raw))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, httpErrors.Unauthorized
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if key.RevokedAt != nil {
+		return nil, httpErrors.Unauthorized
+	}
+
+	// Дата последнего использования справочная: ошибка записи не должна отклонять запрос
+	if err := s.repo.TouchLastUsed(ctx, key.Id); err != nil {
+		s.log.Error("failed to update api key last use", slog.String("api_key", key.Name), sl.Err(err))
+	}
+	return key, nil
+}
+
+// hashKey - SHA-256 достаточно: ключ содержит 256 бит случайных данных, перебор по хэшу бесполезен
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range authz.KnownScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
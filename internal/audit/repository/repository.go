@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"property-managment-service/internal/audit/service"
+	"property-managment-service/internal/models"
+	"strconv"
+	"strings"
+)
+
+type auditRepository struct {
+	Db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) service.AuditRepository {
+	return &auditRepository{Db: db}
+}
+
+const insertEntry = `INSERT INTO audit_log (actor_id, actor_key, request_id, action, entity, entity_id, diff)
+					 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+func (r *auditRepository) CreateWithTx(ctx context.Context, entry *models.AuditEntry, tx *sqlx.Tx) error {
+	const op = "auditRepository.CreateWithTx"
+	if _, err := tx.ExecContext(ctx, insertEntry, entryArgs(entry)...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func entryArgs(entry *models.AuditEntry) []interface{} {
+	return []interface{}{entry.ActorId, entry.ActorKey, entry.RequestId, entry.Action, entry.Entity, entry.EntityId, entry.Diff}
+}
+
+// Find возвращает записи по фильтру, новые первыми
+func (r *auditRepository) Find(ctx context.Context, filter *models.AuditFilter) ([]*models.AuditEntry, error) {
+	const op = "auditRepository.Find"
+
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+	if filter.Entity != "" {
+		where("entity =", filter.Entity)
+	}
+	if filter.EntityId > 0 {
+		where("entity_id =", filter.EntityId)
+	}
+	if filter.ActorId > 0 {
+		where("actor_id =", filter.ActorId)
+	}
+	if filter.From != nil {
+		where("created_at >=", *filter.From)
+	}
+	if filter.To != nil {
+		where("created_at <", *filter.To)
+	}
+	if filter.BeforeId > 0 {
+		where("id <", filter.BeforeId)
+	}
+
+	query := `SELECT * FROM audit_log`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += ` ORDER BY id DESC LIMIT $` + strconv.Itoa(len(args))
+
+	entries := []*models.AuditEntry{}
+	if err := r.Db.SelectContext(ctx, &entries, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/middleware"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/utils"
+	"reflect"
+)
+
+const defaultLimit = 100
+
+type AuditRepository interface {
+	CreateWithTx(ctx context.Context, entry *models.AuditEntry, tx *sqlx.Tx) error
+	Find(ctx context.Context, filter *models.AuditFilter) ([]*models.AuditEntry, error)
+}
+
+// AuditService ведёт журнал изменений. Запись делается в транзакции изменения (RecordWithTx):
+// если журнал не записался, изменение откатывается, поэтому изменений без записи в журнале не бывает.
+// before и after - состояние сущности до и после изменения; nil означает, что её не было.
+type AuditService struct {
+	repo AuditRepository
+	log  *slog.Logger
+}
+
+func NewAuditService(repo AuditRepository, log *slog.Logger) *AuditService {
+	return &AuditService{repo: repo, log: log}
+}
+
+func (s *AuditService) RecordWithTx(ctx context.Context, tx *sqlx.Tx, action string, entity string, entityId int64, before, after interface{}) error {
+	entry, err := newEntry(ctx, action, entity, entityId, before, after)
+	if err != nil || entry == nil {
+		return err
+	}
+	return s.repo.CreateWithTx(ctx, entry, tx)
+}
+
+// Find возвращает страницу журнала, новые записи первыми
+func (s *AuditService) Find(ctx context.Context, filter *models.AuditFilter) (*models.AuditPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	filter.Limit = limit + 1
+	entries, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.AuditPage{Items: entries}
+	if len(entries) > limit {
+		page.Items = entries[:limit]
+		page.NextBeforeId = page.Items[limit-1].Id
+	}
+	return page, nil
+}
+
+// newEntry возвращает nil, если изменение ничего не поменяло
+func newEntry(ctx context.Context, action string, entity string, entityId int64, before, after interface{}) (*models.AuditEntry, error) {
+	diff, err := newDiff(before, after)
+	if err != nil {
+		return nil, fmt.Errorf("audit %s %s %d: %w", action, entity, entityId, err)
+	}
+	if len(diff.Before) == 0 && len(diff.After) == 0 {
+		return nil, nil
+	}
+
+	entry := &models.AuditEntry{Action: action, Entity: entity, EntityId: entityId, Diff: diff}
+	if claims, ok := authz.ClaimsFromContext(ctx); ok {
+		entry.ActorId = &claims.UserId
+	}
+	if key, ok := middleware.ApiKeyFromContext(ctx); ok {
+		entry.ActorKey = &key.Name
+	}
+	if requestId, ok := ctx.Value(utils.ReqIDCtxKey{}).(string); ok {
+		entry.RequestId = requestId
+	}
+	return entry, nil
+}
+
+// newDiff оставляет только поля, значение которых изменилось; при создании и удалении - все поля
+func newDiff(before, after interface{}) (models.AuditDiff, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return models.AuditDiff{}, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return models.AuditDiff{}, err
+	}
+	if beforeFields == nil || afterFields == nil {
+		return models.AuditDiff{Before: beforeFields, After: afterFields}, nil
+	}
+
+	diff := models.AuditDiff{Before: map[string]json.RawMessage{}, After: map[string]json.RawMessage{}}
+	for name, value := range afterFields {
+		if !bytes.Equal(beforeFields[name], value) {
+			diff.Before[name] = beforeFields[name]
+			diff.After[name] = value
+		}
+	}
+	for name, value := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			diff.Before[name] = value
+		}
+	}
+	return diff, nil
+}
+
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("audited value must be a JSON object: %w", err)
+	}
+	return fields, nil
+}
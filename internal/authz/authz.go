@@ -0,0 +1,102 @@
+package authz
+
+import (
+	"context"
+	"property-managment-service/pkg/httpErrors"
+	"time"
+)
+
+type Resource string
+
+const (
+	ResourceProperty        Resource = "property"
+	ResourcePropertyDetails Resource = "propertyDetails"
+	ResourceImage           Resource = "image"
+)
+
+const (
+	RoleAdmin = "admin"
+	RoleHost  = "host"
+	RoleGuest = "guest"
+)
+
+// Области доступа ключей внутренних сервисов
+const (
+	ScopePropertiesRead    = "properties:read"
+	ScopePropertiesWrite   = "properties:write"
+	ScopeAvailabilityRead  = "availability:read"
+	ScopeAvailabilityWrite = "availability:write"
+)
+
+var KnownScopes = []string{ScopePropertiesRead, ScopePropertiesWrite, ScopeAvailabilityRead, ScopeAvailabilityWrite}
+
+// defaultRoles получают токены без claim role/roles: до появления ролей любой пользователь
+// мог и сдавать, и бронировать жильё
+var defaultRoles = []string{RoleHost, RoleGuest}
+
+// Claims - проверенные данные токена пользователя, от имени которого выполняется запрос
+type Claims struct {
+	UserId    int64
+	Roles     []string
+	ExpiresAt time.Time
+}
+
+func NewClaims(userId int64, roles []string, expiresAt time.Time) *Claims {
+	if len(roles) == 0 {
+		roles = defaultRoles
+	}
+	return &Claims{UserId: userId, Roles: roles, ExpiresAt: expiresAt}
+}
+
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Claims) IsAdmin() bool {
+	return c.HasRole(RoleAdmin)
+}
+
+type claimsCtxKey struct{}
+
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey{}, claims)
+}
+
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsCtxKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+type OwnerRepository interface {
+	// GetOwnerId возвращает id владельца объекта недвижимости, к которому относится ресурс
+	GetOwnerId(ctx context.Context, resource Resource, id int64) (int64, error)
+}
+
+// Authorizer проверяет, что пользователь владеет ресурсом. Администратор имеет доступ ко всем ресурсам.
+type Authorizer struct {
+	owners OwnerRepository
+}
+
+func NewAuthorizer(owners OwnerRepository) *Authorizer {
+	return &Authorizer{owners: owners}
+}
+
+func (a *Authorizer) CheckOwner(ctx context.Context, claims *Claims, resource Resource, id int64) error {
+	if claims.IsAdmin() {
+		return nil
+	}
+
+	ownerId, err := a.owners.GetOwnerId(ctx, resource, id)
+	if err != nil {
+		return err
+	}
+	if ownerId != claims.UserId {
+		return httpErrors.NewForbiddenError(nil)
+	}
+	return nil
+}
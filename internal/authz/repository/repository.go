@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"property-managment-service/internal/authz"
+)
+
+// ownerQueries - запросы владельца для каждого типа ресурса; ресурс ищется по своему id
+var ownerQueries = map[authz.Resource]string{
+	authz.ResourceProperty: `SELECT owner_id FROM properties WHERE id = $1`,
+	authz.ResourcePropertyDetails: `SELECT p.owner_id FROM property_details d
+									JOIN properties p ON p.id = d.property_id
+									WHERE d.property_id = $1`,
+	authz.ResourceImage: `SELECT p.owner_id FROM properties_images i
+						  JOIN properties p ON p.id = i.property_id
+						  WHERE i.id = $1`,
+}
+
+type ownerRepository struct {
+	Db *sqlx.DB
+}
+
+func NewOwnerRepository(db *sqlx.DB) authz.OwnerRepository {
+	return &ownerRepository{Db: db}
+}
+
+func (r *ownerRepository) GetOwnerId(ctx context.Context, resource authz.Resource, id int64) (int64, error) {
+	const op = "ownerRepository.GetOwnerId"
+	query, ok := ownerQueries[resource]
+	if !ok {
+		return 0, fmt.Errorf("%s: unknown resource %q", op, resource)
+	}
+
+	var ownerId int64
+	if err := r.Db.QueryRowxContext(ctx, query, id).Scan(&ownerId); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return ownerId, nil
+}
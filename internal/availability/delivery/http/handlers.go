@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"github.com/labstack/echo/v4"
+	"io"
+	"log/slog"
+	"net/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+const maxCalendarSize = 5 << 20
+
+type AvailabilityService interface {
+	BlockDates(ctx context.Context, propertyId int64, req *request.AvailabilityRequest) error
+	// UnblockDates открывает только даты, закрытые вручную; даты из внешних календарей остаются закрытыми
+	UnblockDates(ctx context.Context, propertyId int64, req *request.AvailabilityRequest) error
+	GetCalendar(ctx context.Context, propertyId int64, from string, to string) ([]*models.CalendarDay, error)
+	ExportCalendar(ctx context.Context, propertyId int64) ([]byte, error)
+	ImportCalendar(ctx context.Context, propertyId int64, source string, r io.Reader) (int64, error)
+}
+
+type availabilityFunc func(ctx context.Context, propertyId int64, req *request.AvailabilityRequest) error
+
+type availabilityHandlers struct {
+	availabilityService AvailabilityService
+	log                 *slog.Logger
+}
+
+func NewAvailabilityHandlers(availabilityService AvailabilityService, log *slog.Logger) AvailabilityHandlers {
+	return &availabilityHandlers{availabilityService: availabilityService, log: log}
+}
+
+func (h *availabilityHandlers) GetCalendar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetCalendar", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		calendar, err := h.availabilityService.GetCalendar(ctx, id, c.QueryParam("from"), c.QueryParam("to"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, calendar)
+	}
+}
+
+func (h *availabilityHandlers) ExportCalendar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling ExportCalendar", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		data, err := h.availabilityService.ExportCalendar(ctx, id)
+		if err != nil {
+			return err
+		}
+		return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", data)
+	}
+}
+
+// ImportCalendar принимает .ics как файл multipart-формы (поле file) или как тело запроса text/calendar
+func (h *availabilityHandlers) ImportCalendar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling ImportCalendar", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		var body io.Reader = c.Request().Body
+		if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+			file, err := c.FormFile("file")
+			if err != nil {
+				return httpErrors.NewBadRequestError("file is required")
+			}
+			src, err := file.Open()
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			body = src
+		}
+
+		// Читаем на байт больше лимита: обрезанный документ заменил бы прежний импорт частичным
+		data, err := io.ReadAll(io.LimitReader(body, maxCalendarSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxCalendarSize {
+			return httpErrors.NewRestError(http.StatusRequestEntityTooLarge, "calendar is too large", nil)
+		}
+
+		imported, err := h.availabilityService.ImportCalendar(ctx, id, c.QueryParam("source"), bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, map[string]int64{"imported": imported})
+	}
+}
+
+func (h *availabilityHandlers) BlockDates() echo.HandlerFunc {
+	return h.changeAvailability("BlockDates", h.availabilityService.BlockDates)
+}
+
+func (h *availabilityHandlers) UnblockDates() echo.HandlerFunc {
+	return h.changeAvailability("UnblockDates", h.availabilityService.UnblockDates)
+}
+
+func (h *availabilityHandlers) changeAvailability(name string, fn availabilityFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling "+name, slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		r := &request.AvailabilityRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		if err := fn(ctx, id, r); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
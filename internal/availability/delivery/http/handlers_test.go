@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"property-managment-service/pkg/httpErrors"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fakeAvailabilityService запоминает размер переданного календаря
+type fakeAvailabilityService struct {
+	AvailabilityService
+	received int
+	called   bool
+}
+
+func (s *fakeAvailabilityService) ImportCalendar(_ context.Context, _ int64, _ string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	s.called = true
+	s.received = len(data)
+	return 0, nil
+}
+
+func TestImportCalendarSizeLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		want int
+	}{
+		{name: "small", size: 100, want: http.StatusOK},
+		{name: "at the limit", size: maxCalendarSize, want: http.StatusOK},
+		// Слишком большой документ отклоняется целиком, а не обрезается
+		{name: "over the limit", size: maxCalendarSize + 1, want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeAvailabilityService{}
+			h := &availabilityHandlers{
+				availabilityService: service,
+				log:                 slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/properties/1/availability/import?source=airbnb",
+				strings.NewReader(strings.Repeat("x", tt.size)))
+			req.Header.Set(echo.HeaderContentType, "text/calendar")
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			status := rec.Code
+			if err := h.ImportCalendar()(c); err != nil {
+				var restErr httpErrors.RestErr
+				if !errors.As(err, &restErr) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				status = restErr.Status()
+			}
+			if status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+			if tt.want == http.StatusOK && service.received != tt.size {
+				t.Errorf("service received %d bytes, want %d", service.received, tt.size)
+			}
+			if tt.want != http.StatusOK && service.called {
+				t.Error("oversized calendar was passed to the service")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/middleware"
+)
+
+type AvailabilityHandlers interface {
+	GetCalendar() echo.HandlerFunc
+	ExportCalendar() echo.HandlerFunc
+	ImportCalendar() echo.HandlerFunc
+	BlockDates() echo.HandlerFunc
+	UnblockDates() echo.HandlerFunc
+}
+
+func MapAvailabilityRoutes(propertyGroup *echo.Group, h AvailabilityHandlers, mw *middleware.MiddlewareManager) {
+	read := mw.APIKeyOr(authz.ScopeAvailabilityRead, nil)
+	propertyGroup.GET("/:id/calendar", h.GetCalendar(), read)
+	propertyGroup.GET("/:id/calendar.ics", h.ExportCalendar(), read)
+	write := mw.APIKeyOr(authz.ScopeAvailabilityWrite, mw.AuthJWTMiddleware())
+	owner := mw.RequireOwner(authz.ResourceProperty, middleware.PathParam("id"))
+	propertyGroup.POST("/:id/calendar/import", h.ImportCalendar(), write, owner)
+	propertyGroup.POST("/:id/availability/block", h.BlockDates(), write, owner)
+	propertyGroup.POST("/:id/availability/unblock", h.UnblockDates(), write, owner)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"property-managment-service/internal/availability/service"
+	"property-managment-service/internal/models"
+)
+
+type availabilityRepository struct {
+	Db *sqlx.DB
+}
+
+func NewAvailabilityRepository(db *sqlx.DB) service.AvailabilityRepository {
+	return &availabilityRepository{Db: db}
+}
+
+func (r *availabilityRepository) BlockDates(ctx context.Context, propertyId int64, dates []string) error {
+	const op = "availabilityRepository.BlockDates"
+	query := `INSERT INTO property_availability (property_id, date, is_available, source)
+			  SELECT $1, d, FALSE, 'manual' FROM unnest($2::date[]) AS d
+			  ON CONFLICT (property_id, date, source) DO UPDATE SET is_available = FALSE`
+	if _, err := r.Db.ExecContext(ctx, query, propertyId, dates); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UnblockDates снимает только ручную блокировку; импортированные даты заменяет повторный импорт
+func (r *availabilityRepository) UnblockDates(ctx context.Context, propertyId int64, dates []string) error {
+	const op = "availabilityRepository.UnblockDates"
+	query := `DELETE FROM property_availability
+			  WHERE property_id = $1 AND source = 'manual' AND date = ANY($2::date[])`
+	if _, err := r.Db.ExecContext(ctx, query, propertyId, dates); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetByPeriod возвращает строки всех источников; на одну дату их может быть несколько
+func (r *availabilityRepository) GetByPeriod(ctx context.Context, propertyId int64, from string, to string) ([]*models.PropertyAvailability, error) {
+	const op = "availabilityRepository.GetByPeriod"
+	query := `SELECT id, property_id, to_char(date, 'YYYY-MM-DD') AS date, is_available, source
+			  FROM property_availability
+			  WHERE property_id = $1 AND date BETWEEN $2::date AND $3::date
+			  ORDER BY date`
+	rows, err := r.Db.QueryxContext(ctx, query, propertyId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	days := []*models.PropertyAvailability{}
+	for rows.Next() {
+		day := &models.PropertyAvailability{}
+		if err := rows.StructScan(day); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		days = append(days, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return days, nil
+}
+
+// GetBookedRanges возвращает подтверждённые бронирования объекта, пересекающиеся с [from, to]
+func (r *availabilityRepository) GetBookedRanges(ctx context.Context, propertyId int64, from string, to string) ([]*models.DateRange, error) {
+	const op = "availabilityRepository.GetBookedRanges"
+	query := `SELECT to_char(check_in_date, 'YYYY-MM-DD') AS check_in_date,
+					 to_char(check_out_date, 'YYYY-MM-DD') AS check_out_date
+			  FROM bookings
+			  WHERE property_id = $1
+			    AND status = 'confirmed'
+			    AND daterange(check_in_date, check_out_date, '[)') && daterange($2::date, $3::date, '[]')
+			  ORDER BY check_in_date`
+	rows, err := r.Db.QueryxContext(ctx, query, propertyId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	ranges := []*models.DateRange{}
+	for rows.Next() {
+		dateRange := &models.DateRange{}
+		if err := rows.StructScan(dateRange); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		ranges = append(ranges, dateRange)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ranges, nil
+}
+
+// GetBlockedDates возвращает даты начиная с from, закрытые вручную владельцем
+func (r *availabilityRepository) GetBlockedDates(ctx context.Context, propertyId int64, from string) ([]string, error) {
+	const op = "availabilityRepository.GetBlockedDates"
+	query := `SELECT to_char(date, 'YYYY-MM-DD') FROM property_availability
+			  WHERE property_id = $1 AND is_available = FALSE AND source = 'manual' AND date >= $2::date
+			  ORDER BY date`
+	dates := []string{}
+	if err := r.Db.SelectContext(ctx, &dates, query, propertyId, from); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return dates, nil
+}
+
+// GetActiveBookings возвращает активные бронирования объекта, не закончившиеся к from
+func (r *availabilityRepository) GetActiveBookings(ctx context.Context, propertyId int64, from string) ([]*models.Booking, error) {
+	const op = "availabilityRepository.GetActiveBookings"
+	query := `SELECT id, property_id, user_id,
+					 to_char(check_in_date, 'YYYY-MM-DD') AS check_in_date,
+					 to_char(check_out_date, 'YYYY-MM-DD') AS check_out_date,
+					 total_price, status,
+					 to_char(created_at, 'YYYY-MM-DD') AS created_at
+			  FROM bookings
+			  WHERE property_id = $1 AND status IN ('pending', 'confirmed') AND check_out_date > $2::date
+			  ORDER BY check_in_date`
+	bookings := []*models.Booking{}
+	if err := r.Db.SelectContext(ctx, &bookings, query, propertyId, from); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return bookings, nil
+}
+
+func (r *availabilityRepository) DeleteBySourceWithTx(ctx context.Context, propertyId int64, source string, tx *sqlx.Tx) error {
+	const op = "availabilityRepository.DeleteBySourceWithTx"
+	query := `DELETE FROM property_availability WHERE property_id = $1 AND source = $2`
+	if _, err := tx.ExecContext(ctx, query, propertyId, source); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// BlockImportedDatesWithTx закрывает даты от имени source. Строки вручную закрытых дат
+// и других источников не затрагиваются: у каждого источника свои строки.
+func (r *availabilityRepository) BlockImportedDatesWithTx(ctx context.Context, propertyId int64, source string, dates []string, tx *sqlx.Tx) (int64, error) {
+	const op = "availabilityRepository.BlockImportedDatesWithTx"
+	query := `INSERT INTO property_availability (property_id, date, is_available, source)
+			  SELECT $1, d, FALSE, $2 FROM unnest($3::date[]) AS d
+			  ON CONFLICT (property_id, date, source) DO NOTHING`
+	result, err := tx.ExecContext(ctx, query, propertyId, source, dates)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/ical"
+	"regexp"
+	"time"
+)
+
+const (
+	icalProdID = "-//Rentology//property-management-service//RU"
+	icalDomain = "property-management-service"
+
+	// maxImportDays - насколько далеко вперёд импортируются занятые даты
+	maxImportDays = 730
+)
+
+var sourcePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// ExportCalendar формирует .ics со всеми активными бронированиями и закрытыми вручную датами объекта.
+// Импортированные из других календарей даты не выгружаются, чтобы площадки не синхронизировали их по кругу.
+func (s *availabilityService) ExportCalendar(ctx context.Context, propertyId int64) ([]byte, error) {
+	property, err := s.propertyService.GetById(ctx, propertyId)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().Format(dateLayout)
+
+	bookings, err := s.availabilityRepo.GetActiveBookings(ctx, propertyId, today)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := s.availabilityRepo.GetBlockedDates(ctx, propertyId, today)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Calendar{ProdID: icalProdID, Name: property.Title}
+	for _, booking := range bookings {
+		checkIn, err := time.Parse(dateLayout, booking.CheckInDate)
+		if err != nil {
+			return nil, err
+		}
+		checkOut, err := time.Parse(dateLayout, booking.CheckOutDate)
+		if err != nil {
+			return nil, err
+		}
+		status := "CONFIRMED"
+		if booking.Status == "pending" {
+			status = "TENTATIVE"
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:     fmt.Sprintf("booking-%d@%s", booking.Id, icalDomain),
+			Summary: "Reserved",
+			Start:   checkIn,
+			End:     checkOut,
+			AllDay:  true,
+			Status:  status,
+		})
+	}
+
+	ranges, err := groupDates(blocked)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ranges {
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:     fmt.Sprintf("blocked-%d-%s@%s", propertyId, r[0].Format("20060102"), icalDomain),
+			Summary: "Not available",
+			Start:   r[0],
+			End:     r[1],
+			AllDay:  true,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.Encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportCalendar закрывает даты, занятые событиями VEVENT документа, от имени source.
+// Ранее импортированные из того же source даты заменяются целиком.
+func (s *availabilityService) ImportCalendar(ctx context.Context, propertyId int64, source string, r io.Reader) (int64, error) {
+	if !sourcePattern.MatchString(source) || source == "manual" {
+		return 0, httpErrors.NewRestError(http.StatusBadRequest, "invalid source", nil)
+	}
+
+	events, err := ical.Parse(r)
+	if err != nil {
+		if errors.Is(err, ical.ErrInvalidCalendar) {
+			return 0, httpErrors.NewRestError(http.StatusBadRequest, err.Error(), err)
+		}
+		return 0, err
+	}
+
+	dates := importedDates(events, time.Now())
+
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := s.availabilityRepo.DeleteBySourceWithTx(ctx, propertyId, source, tx); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	imported, err := s.availabilityRepo.BlockImportedDatesWithTx(ctx, propertyId, source, dates, tx)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	s.log.Info("ImportCalendar", "property id", propertyId, "source", source, "events", len(events), "imported days", imported)
+	return imported, nil
+}
+
+// importedDates возвращает даты, занятые неотменёнными событиями, в пределах maxImportDays от now
+func importedDates(events []ical.Event, now time.Time) []string {
+	to := now.AddDate(0, 0, maxImportDays)
+
+	seen := make(map[string]bool)
+	dates := []string{}
+	for _, event := range events {
+		if event.Status == "CANCELLED" {
+			continue
+		}
+		for _, date := range event.Days(now, to) {
+			if seen[date] {
+				continue
+			}
+			seen[date] = true
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// groupDates объединяет отсортированные даты в непрерывные полуинтервалы [start, end)
+func groupDates(dates []string) ([][2]time.Time, error) {
+	var ranges [][2]time.Time
+	for _, date := range dates {
+		d, err := time.Parse(dateLayout, date)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(ranges); n > 0 && ranges[n-1][1].Equal(d) {
+			ranges[n-1][1] = d.AddDate(0, 0, 1)
+			continue
+		}
+		ranges = append(ranges, [2]time.Time{d, d.AddDate(0, 0, 1)})
+	}
+	return ranges, nil
+}
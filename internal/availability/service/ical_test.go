@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"property-managment-service/internal/models"
+	propertyHttp "property-managment-service/internal/property/delivery/http"
+	"property-managment-service/pkg/db/dbtest"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/ical"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeAvailabilityRepo хранит импортированные даты в памяти: source -> даты
+type fakeAvailabilityRepo struct {
+	AvailabilityRepository
+	imported map[string][]string
+}
+
+func (r *fakeAvailabilityRepo) DeleteBySourceWithTx(_ context.Context, _ int64, source string, _ *sqlx.Tx) error {
+	delete(r.imported, source)
+	return nil
+}
+
+func (r *fakeAvailabilityRepo) BlockImportedDatesWithTx(_ context.Context, _ int64, source string, dates []string, _ *sqlx.Tx) (int64, error) {
+	r.imported[source] = append(r.imported[source], dates...)
+	return int64(len(dates)), nil
+}
+
+func (r *fakeAvailabilityRepo) GetByPeriod(_ context.Context, propertyId int64, from string, to string) ([]*models.PropertyAvailability, error) {
+	days := []*models.PropertyAvailability{}
+	for source, dates := range r.imported {
+		for _, date := range dates {
+			if date >= from && date <= to {
+				days = append(days, &models.PropertyAvailability{PropertyId: propertyId, Date: date, Source: source})
+			}
+		}
+	}
+	return days, nil
+}
+
+func (r *fakeAvailabilityRepo) GetBookedRanges(context.Context, int64, string, string) ([]*models.DateRange, error) {
+	return []*models.DateRange{}, nil
+}
+
+type fakePropertyService struct {
+	propertyHttp.PropertyService
+}
+
+func (fakePropertyService) GetById(_ context.Context, id int64) (*models.Property, error) {
+	return &models.Property{ID: id}, nil
+}
+
+func newImportService(repo AvailabilityRepository) (*availabilityService, *dbtest.TransactionManager) {
+	tm := dbtest.NewTransactionManager()
+	return &availabilityService{
+		log:                slog.New(slog.NewTextHandler(io.Discard, nil)),
+		availabilityRepo:   repo,
+		propertyService:    fakePropertyService{},
+		transactionManager: tm,
+	}, tm
+}
+
+func parseFixture(t *testing.T, name string) []ical.Event {
+	t.Helper()
+	f, err := os.Open(filepath.Join("..", "..", "..", "pkg", "ical", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	events, err := ical.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestImportedDates(t *testing.T) {
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		fixture string
+		want    []string
+	}{
+		{
+			name:    "cancelled events are skipped",
+			fixture: "cancelled.ics",
+			want:    []string{"2030-06-01", "2030-06-02"},
+		},
+		{
+			name:    "all-day events",
+			fixture: "allday.ics",
+			want:    []string{"2030-05-01", "2030-05-02", "2030-05-03", "2030-05-10"},
+		},
+		{
+			name:    "duration",
+			fixture: "duration.ics",
+			want: []string{
+				"2030-04-01", "2030-04-02", "2030-04-03", "2030-04-04", "2030-04-05", "2030-04-06", "2030-04-07",
+				"2030-04-10", "2030-04-11", "2030-04-12",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := importedDates(parseFixture(t, tt.fixture), now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("importedDates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportedDatesWindow(t *testing.T) {
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// Событие длиной в 10 000 лет обрезается окном импорта
+	got := importedDates(parseFixture(t, "huge.ics"), now)
+	if len(got) != maxImportDays {
+		t.Fatalf("got %d dates, want %d", len(got), maxImportDays)
+	}
+	if got[0] != "2030-01-01" || got[len(got)-1] != now.AddDate(0, 0, maxImportDays-1).Format(dateLayout) {
+		t.Errorf("window = %s..%s", got[0], got[len(got)-1])
+	}
+
+	// Пересекающиеся события не дают повторов
+	events := []ical.Event{
+		{Start: now.AddDate(0, 0, 1), End: now.AddDate(0, 0, 3), AllDay: true},
+		{Start: now.AddDate(0, 0, 2), End: now.AddDate(0, 0, 4), AllDay: true},
+		{Start: now.AddDate(0, 0, -10), End: now.AddDate(0, 0, -5), AllDay: true},
+	}
+	want := []string{"2030-01-02", "2030-01-03", "2030-01-04"}
+	if got := importedDates(events, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("importedDates = %v, want %v", got, want)
+	}
+}
+
+func TestImportCalendarReplacesPreviousImport(t *testing.T) {
+	repo := &fakeAvailabilityRepo{imported: map[string][]string{"other": {"2099-01-01"}}}
+	s, tm := newImportService(repo)
+	ctx := context.Background()
+	first := time.Now().AddDate(0, 0, 10)
+
+	calendar := func(events ...ical.Event) io.Reader {
+		var b strings.Builder
+		if err := (&ical.Calendar{ProdID: "-//Test//RU", Events: events}).Encode(&b); err != nil {
+			t.Fatal(err)
+		}
+		return strings.NewReader(b.String())
+	}
+	day := func(offset int) time.Time {
+		d := first.AddDate(0, 0, offset)
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	imported, err := s.ImportCalendar(ctx, 1, "airbnb", calendar(ical.Event{UID: "a", Start: day(0), End: day(3), AllDay: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 3 {
+		t.Errorf("first import = %d days, want 3", imported)
+	}
+
+	imported, err = s.ImportCalendar(ctx, 1, "airbnb", calendar(ical.Event{UID: "b", Start: day(5), End: day(7), AllDay: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 2 {
+		t.Errorf("second import = %d days, want 2", imported)
+	}
+
+	got := append([]string(nil), repo.imported["airbnb"]...)
+	sort.Strings(got)
+	want := []string{day(5).Format(dateLayout), day(6).Format(dateLayout)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("airbnb dates = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(repo.imported["other"], []string{"2099-01-01"}) {
+		t.Errorf("dates of another source changed: %v", repo.imported["other"])
+	}
+	if tm.Commits() != 2 {
+		t.Errorf("commits = %d, want 2", tm.Commits())
+	}
+}
+
+func TestImportCalendarRejectsBadInput(t *testing.T) {
+	s, tm := newImportService(&fakeAvailabilityRepo{imported: map[string][]string{}})
+	tests := []struct {
+		name   string
+		source string
+		body   string
+	}{
+		{name: "manual source", source: "manual", body: "BEGIN:VCALENDAR\nEND:VCALENDAR\n"},
+		{name: "bad source", source: "Air BnB", body: "BEGIN:VCALENDAR\nEND:VCALENDAR\n"},
+		{name: "not a calendar", source: "airbnb", body: "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ImportCalendar(context.Background(), 1, tt.source, strings.NewReader(tt.body))
+			var restErr httpErrors.RestErr
+			if !errors.As(err, &restErr) || restErr.Status() != http.StatusBadRequest {
+				t.Errorf("err = %v, want 400", err)
+			}
+		})
+	}
+	if tm.Commits() != 0 {
+		t.Errorf("commits = %d, want 0", tm.Commits())
+	}
+}
+
+// Дата, закрытая двумя календарями, остаётся закрытой, пока её не освободят оба
+func TestImportCalendarKeepsDatesOfOtherSources(t *testing.T) {
+	repo := &fakeAvailabilityRepo{imported: map[string][]string{}}
+	s, _ := newImportService(repo)
+	ctx := context.Background()
+
+	d := time.Now().AddDate(0, 0, 10)
+	day := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	date := day.Format(dateLayout)
+	calendar := func(events ...ical.Event) io.Reader {
+		var b strings.Builder
+		if err := (&ical.Calendar{ProdID: "-//Test//RU", Events: events}).Encode(&b); err != nil {
+			t.Fatal(err)
+		}
+		return strings.NewReader(b.String())
+	}
+	status := func() string {
+		t.Helper()
+		calendar, err := s.GetCalendar(ctx, 1, date, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return calendar[0].Status
+	}
+
+	event := ical.Event{UID: "stay", Start: day, End: day.AddDate(0, 0, 1), AllDay: true}
+	for _, source := range []string{"airbnb", "booking"} {
+		if _, err := s.ImportCalendar(ctx, 1, source, calendar(event)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := status(); got != DayBlocked {
+		t.Fatalf("status after both imports = %s, want %s", got, DayBlocked)
+	}
+
+	if _, err := s.ImportCalendar(ctx, 1, "airbnb", calendar()); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != DayBlocked {
+		t.Errorf("status after airbnb released the day = %s, want %s", got, DayBlocked)
+	}
+
+	if _, err := s.ImportCalendar(ctx, 1, "booking", calendar()); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != DayAvailable {
+		t.Errorf("status after both released the day = %s, want %s", got, DayAvailable)
+	}
+}
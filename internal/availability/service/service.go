@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+	"net/http"
+	availabilityHttp "property-managment-service/internal/availability/delivery/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	propertyHttp "property-managment-service/internal/property/delivery/http"
+	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/httpErrors"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// maxPeriodDays ограничивает длину диапазона в одном запросе
+	maxPeriodDays       = 366
+	defaultCalendarDays = 30
+
+	DayAvailable = "available"
+	DayBlocked   = "blocked"
+	DayBooked    = "booked"
+)
+
+type AvailabilityRepository interface {
+	BlockDates(ctx context.Context, propertyId int64, dates []string) error
+	UnblockDates(ctx context.Context, propertyId int64, dates []string) error
+	GetByPeriod(ctx context.Context, propertyId int64, from string, to string) ([]*models.PropertyAvailability, error)
+	GetBookedRanges(ctx context.Context, propertyId int64, from string, to string) ([]*models.DateRange, error)
+	GetBlockedDates(ctx context.Context, propertyId int64, from string) ([]string, error)
+	GetActiveBookings(ctx context.Context, propertyId int64, from string) ([]*models.Booking, error)
+	DeleteBySourceWithTx(ctx context.Context, propertyId int64, source string, tx *sqlx.Tx) error
+	BlockImportedDatesWithTx(ctx context.Context, propertyId int64, source string, dates []string, tx *sqlx.Tx) (int64, error)
+}
+
+type availabilityService struct {
+	log                *slog.Logger
+	availabilityRepo   AvailabilityRepository
+	propertyService    propertyHttp.PropertyService
+	transactionManager db.TransactionManager
+}
+
+func NewAvailabilityService(
+	availabilityRepo AvailabilityRepository,
+	propertyService propertyHttp.PropertyService,
+	transactionManager db.TransactionManager,
+	log *slog.Logger,
+) availabilityHttp.AvailabilityService {
+	return &availabilityService{
+		log:                log,
+		availabilityRepo:   availabilityRepo,
+		propertyService:    propertyService,
+		transactionManager: transactionManager,
+	}
+}
+
+func (s *availabilityService) BlockDates(ctx context.Context, propertyId int64, req *request.AvailabilityRequest) error {
+	dates, err := expandDates(req)
+	if err != nil {
+		return err
+	}
+	s.log.Info("BlockDates", "property id", propertyId, "days", len(dates))
+	return s.availabilityRepo.BlockDates(ctx, propertyId, dates)
+}
+
+func (s *availabilityService) UnblockDates(ctx context.Context, propertyId int64, req *request.AvailabilityRequest) error {
+	dates, err := expandDates(req)
+	if err != nil {
+		return err
+	}
+	s.log.Info("UnblockDates", "property id", propertyId, "days", len(dates))
+	return s.availabilityRepo.UnblockDates(ctx, propertyId, dates)
+}
+
+func (s *availabilityService) GetCalendar(ctx context.Context, propertyId int64, from string, to string) ([]*models.CalendarDay, error) {
+	fromDate, toDate, err := calendarPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.propertyService.GetById(ctx, propertyId); err != nil {
+		return nil, err
+	}
+
+	from, to = fromDate.Format(dateLayout), toDate.Format(dateLayout)
+
+	rows, err := s.availabilityRepo.GetByPeriod(ctx, propertyId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	booked, err := s.availabilityRepo.GetBookedRanges(ctx, propertyId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Дата закрыта, если её закрыл владелец или хотя бы один импортированный календарь
+	statuses := make(map[string]string)
+	for _, day := range rows {
+		if !day.IsAvailable {
+			statuses[day.Date] = DayBlocked
+		}
+	}
+	// Подтверждённое бронирование важнее ручной блокировки
+	for _, dateRange := range booked {
+		checkIn, err := time.Parse(dateLayout, dateRange.CheckIn)
+		if err != nil {
+			return nil, err
+		}
+		checkOut, err := time.Parse(dateLayout, dateRange.CheckOut)
+		if err != nil {
+			return nil, err
+		}
+		for d := checkIn; d.Before(checkOut); d = d.AddDate(0, 0, 1) {
+			statuses[d.Format(dateLayout)] = DayBooked
+		}
+	}
+
+	calendar := []*models.CalendarDay{}
+	for d := fromDate; !d.After(toDate); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateLayout)
+		status, ok := statuses[date]
+		if !ok {
+			status = DayAvailable
+		}
+		calendar = append(calendar, &models.CalendarDay{Date: date, Available: status == DayAvailable, Status: status})
+	}
+	return calendar, nil
+}
+
+func expandDates(req *request.AvailabilityRequest) ([]string, error) {
+	if (req.From == "") != (req.To == "") {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "from and to must be set together", nil)
+	}
+	if req.From == "" && len(req.Dates) == 0 {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "either from/to or dates must be set", nil)
+	}
+
+	seen := make(map[string]bool)
+	dates := []string{}
+	add := func(date string) {
+		if !seen[date] {
+			seen[date] = true
+			dates = append(dates, date)
+		}
+	}
+
+	if req.From != "" {
+		from, to, err := parsePeriod(req.From, req.To)
+		if err != nil {
+			return nil, err
+		}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			add(d.Format(dateLayout))
+		}
+	}
+	for _, date := range req.Dates {
+		add(date)
+	}
+
+	if len(dates) > maxPeriodDays {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "too many dates in one request", nil)
+	}
+	return dates, nil
+}
+
+// calendarPeriod возвращает период календаря; по умолчанию - defaultCalendarDays дней начиная с сегодняшнего
+func calendarPeriod(from string, to string) (time.Time, time.Time, error) {
+	if from == "" {
+		from = time.Now().Format(dateLayout)
+	}
+	if to == "" {
+		fromDate, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, httpErrors.NewRestError(http.StatusBadRequest, "invalid from", nil)
+		}
+		to = fromDate.AddDate(0, 0, defaultCalendarDays-1).Format(dateLayout)
+	}
+	return parsePeriod(from, to)
+}
+
+func parsePeriod(from string, to string) (time.Time, time.Time, error) {
+	fromDate, err := time.Parse(dateLayout, from)
+	if err != nil {
+		return time.Time{}, time.Time{}, httpErrors.NewRestError(http.StatusBadRequest, "invalid from", nil)
+	}
+	toDate, err := time.Parse(dateLayout, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, httpErrors.NewRestError(http.StatusBadRequest, "invalid to", nil)
+	}
+	if toDate.Before(fromDate) {
+		return time.Time{}, time.Time{}, httpErrors.NewRestError(http.StatusBadRequest, "to must not be before from", nil)
+	}
+	if int(toDate.Sub(fromDate).Hours()/24) >= maxPeriodDays {
+		return time.Time{}, time.Time{}, httpErrors.NewRestError(http.StatusBadRequest, "period is too long", nil)
+	}
+	return fromDate, toDate, nil
+}
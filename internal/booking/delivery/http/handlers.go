@@ -0,0 +1,219 @@
+package http
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"net/http"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/middleware"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"strconv"
+)
+
+type BookingService interface {
+	Create(ctx context.Context, userId int64, req *request.CreateBookingRequest) (*models.Booking, error)
+	GetById(ctx context.Context, id int64) (*models.Booking, error)
+	GetByUserId(ctx context.Context, userId int64) ([]*models.Booking, error)
+	GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Booking, error)
+	Confirm(ctx context.Context, id int64, userId int64, reason string) (*models.Booking, error)
+	Decline(ctx context.Context, id int64, userId int64, reason string) (*models.Booking, error)
+	Cancel(ctx context.Context, id int64, userId int64, reason string) (*models.Booking, error)
+	GetStatusHistory(ctx context.Context, id int64) ([]*models.BookingStatusChange, error)
+	ExpirePending(ctx context.Context) (int, error)
+}
+
+type transitionFunc func(ctx context.Context, id int64, userId int64, reason string) (*models.Booking, error)
+
+type bookingHandlers struct {
+	bookingService BookingService
+	authorizer     *authz.Authorizer
+	log            *slog.Logger
+}
+
+func NewBookingHandlers(bookingService BookingService, authorizer *authz.Authorizer, log *slog.Logger) BookingHandlers {
+	return &bookingHandlers{bookingService: bookingService, authorizer: authorizer, log: log}
+}
+
+func (h *bookingHandlers) CreateBooking() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling CreateBooking", slog.String("request_id", requestID))
+		r := &request.CreateBookingRequest{}
+
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		booking, err := h.bookingService.Create(ctx, claims.UserId, r)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, booking)
+	}
+}
+
+func (h *bookingHandlers) GetBookingById() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetBookingById", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		booking, err := h.bookingService.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := h.checkParticipant(ctx, claims, booking); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, booking)
+	}
+}
+
+func (h *bookingHandlers) GetBookings() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetBookings", slog.String("request_id", requestID))
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		if propertyIdParam := c.QueryParam("propertyId"); propertyIdParam != "" {
+			// Бронирования конкретного объекта доступны его владельцу и администратору
+			propertyId, err := strconv.ParseInt(propertyIdParam, 10, 64)
+			if err != nil {
+				return httpErrors.NewBadRequestError("invalid propertyId")
+			}
+			if err := h.authorizer.CheckOwner(ctx, claims, authz.ResourceProperty, propertyId); err != nil {
+				return err
+			}
+
+			bookings, err := h.bookingService.GetByPropertyId(ctx, propertyId)
+			if err != nil {
+				return err
+			}
+			return c.JSON(http.StatusOK, bookings)
+		}
+
+		// Бронирования гостя: по умолчанию текущего пользователя
+		userId := claims.UserId
+		if guestIdParam := c.QueryParam("guestId"); guestIdParam != "" {
+			guestId, err := strconv.ParseInt(guestIdParam, 10, 64)
+			if err != nil {
+				return httpErrors.NewBadRequestError("invalid guestId")
+			}
+			// Чужие бронирования доступны только администратору
+			if guestId != userId && !claims.IsAdmin() {
+				return httpErrors.NewForbiddenError(nil)
+			}
+			userId = guestId
+		}
+
+		bookings, err := h.bookingService.GetByUserId(ctx, userId)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, bookings)
+	}
+}
+
+func (h *bookingHandlers) ConfirmBooking() echo.HandlerFunc {
+	return h.transition("ConfirmBooking", h.bookingService.Confirm)
+}
+
+func (h *bookingHandlers) DeclineBooking() echo.HandlerFunc {
+	return h.transition("DeclineBooking", h.bookingService.Decline)
+}
+
+func (h *bookingHandlers) CancelBooking() echo.HandlerFunc {
+	return h.transition("CancelBooking", h.bookingService.Cancel)
+}
+
+func (h *bookingHandlers) GetBookingHistory() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetBookingHistory", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		// История доступна тем же, кто может видеть само бронирование
+		booking, err := h.bookingService.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := h.checkParticipant(ctx, claims, booking); err != nil {
+			return err
+		}
+
+		history, err := h.bookingService.GetStatusHistory(ctx, id)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, history)
+	}
+}
+
+func (h *bookingHandlers) transition(name string, fn transitionFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling "+name, slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		r := &request.BookingTransitionRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		booking, err := fn(ctx, id, claims.UserId, r.Reason)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, booking)
+	}
+}
+
+// checkParticipant пропускает гостя, владельца объекта и администратора
+func (h *bookingHandlers) checkParticipant(ctx context.Context, claims *authz.Claims, booking *models.Booking) error {
+	if booking.UserId == claims.UserId {
+		return nil
+	}
+	return h.authorizer.CheckOwner(ctx, claims, authz.ResourceProperty, booking.PropertyId)
+}
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/middleware"
+)
+
+type BookingHandlers interface {
+	CreateBooking() echo.HandlerFunc
+	GetBookingById() echo.HandlerFunc
+	GetBookings() echo.HandlerFunc
+	ConfirmBooking() echo.HandlerFunc
+	DeclineBooking() echo.HandlerFunc
+	CancelBooking() echo.HandlerFunc
+	GetBookingHistory() echo.HandlerFunc
+}
+
+func MapBookingRoutes(bookingGroup *echo.Group, h BookingHandlers, mw *middleware.MiddlewareManager) {
+	bookingGroup.POST("", h.CreateBooking(), mw.AuthJWTMiddleware(), mw.RequireRole(authz.RoleGuest))
+	bookingGroup.GET("", h.GetBookings(), mw.AuthJWTMiddleware())
+	bookingGroup.GET("/:id", h.GetBookingById(), mw.AuthJWTMiddleware())
+	bookingGroup.GET("/:id/history", h.GetBookingHistory(), mw.AuthJWTMiddleware())
+	bookingGroup.POST("/:id/confirm", h.ConfirmBooking(), mw.AuthJWTMiddleware())
+	bookingGroup.POST("/:id/decline", h.DeclineBooking(), mw.AuthJWTMiddleware())
+	bookingGroup.POST("/:id/cancel", h.CancelBooking(), mw.AuthJWTMiddleware())
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"property-managment-service/internal/booking/service"
+	"property-managment-service/internal/models"
+	"time"
+)
+
+type bookingRepository struct {
+	Db *sqlx.DB
+}
+
+func NewBookingRepository(db *sqlx.DB) service.BookingRepository {
+	return &bookingRepository{Db: db}
+}
+
+func (r *bookingRepository) CreateWithTx(ctx context.Context, booking *models.Booking, tx *sqlx.Tx) (*models.Booking, error) {
+	const op = "bookingRepository.CreateWithTx"
+	query := `INSERT INTO bookings (property_id, user_id, check_in_date, check_out_date, total_price, status, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *`
+	if err := tx.QueryRowxContext(ctx, query, booking.PropertyId, booking.UserId, booking.CheckInDate,
+		booking.CheckOutDate, booking.TotalPrice, booking.Status, booking.CreatedAt).StructScan(booking); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return booking, nil
+}
+
+// HasOverlapWithTx проверяет, есть ли у объекта активные бронирования, пересекающиеся с [checkIn, checkOut)
+func (r *bookingRepository) HasOverlapWithTx(ctx context.Context, propertyId int64, checkIn string, checkOut string, tx *sqlx.Tx) (bool, error) {
+	const op = "bookingRepository.HasOverlapWithTx"
+	query := `SELECT EXISTS (
+				SELECT 1 FROM bookings
+				WHERE property_id = $1
+				  AND status NOT IN ('cancelled', 'declined')
+				  AND daterange(check_in_date, check_out_date, '[)') && daterange($2::date, $3::date, '[)')
+			  )`
+	var exists bool
+	if err := tx.QueryRowxContext(ctx, query, propertyId, checkIn, checkOut).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exists, nil
+}
+
+// HasBlockedDatesWithTx проверяет, закрыты ли владельцем какие-либо даты из [checkIn, checkOut)
+func (r *bookingRepository) HasBlockedDatesWithTx(ctx context.Context, propertyId int64, checkIn string, checkOut string, tx *sqlx.Tx) (bool, error) {
+	const op = "bookingRepository.HasBlockedDatesWithTx"
+	query := `SELECT EXISTS (
+				SELECT 1 FROM property_availability
+				WHERE property_id = $1
+				  AND is_available = FALSE
+				  AND date >= $2::date AND date < $3::date
+			  )`
+	var exists bool
+	if err := tx.QueryRowxContext(ctx, query, propertyId, checkIn, checkOut).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exists, nil
+}
+
+// GetPropertyForShareWithTx блокирует объявление до конца транзакции, чтобы его не архивировали
+// и не сняли с публикации одновременно с созданием или подтверждением брони
+func (r *bookingRepository) GetPropertyForShareWithTx(ctx context.Context, propertyId int64, tx *sqlx.Tx) (*models.Property, error) {
+	const op = "bookingRepository.GetPropertyForShareWithTx"
+	query := `SELECT * FROM properties WHERE id = $1 FOR SHARE`
+	property := &models.Property{}
+	if err := tx.QueryRowxContext(ctx, query, propertyId).StructScan(property); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return property, nil
+}
+
+func (r *bookingRepository) GetById(ctx context.Context, id int64) (*models.Booking, error) {
+	const op = "bookingRepository.GetById"
+	query := `SELECT * FROM bookings WHERE id = $1`
+	booking := &models.Booking{}
+	if err := r.Db.QueryRowxContext(ctx, query, id).StructScan(booking); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return booking, nil
+}
+
+func (r *bookingRepository) GetByUserId(ctx context.Context, userId int64) ([]*models.Booking, error) {
+	const op = "bookingRepository.GetByUserId"
+	query := `SELECT * FROM bookings WHERE user_id = $1 ORDER BY check_in_date DESC`
+	return r.selectBookings(ctx, op, query, userId)
+}
+
+func (r *bookingRepository) GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Booking, error) {
+	const op = "bookingRepository.GetByPropertyId"
+	query := `SELECT * FROM bookings WHERE property_id = $1 ORDER BY check_in_date`
+	return r.selectBookings(ctx, op, query, propertyId)
+}
+
+// GetExpiredPending возвращает заявки старше ttl и заявки, дата заезда которых уже наступила.
+// Возраст заявки считается по часам БД, как и requested_at.
+func (r *bookingRepository) GetExpiredPending(ctx context.Context, ttl time.Duration, checkInBefore string) ([]*models.Booking, error) {
+	const op = "bookingRepository.GetExpiredPending"
+	query := `SELECT * FROM bookings
+			  WHERE status = 'pending' AND (requested_at < NOW() - make_interval(secs => $1) OR check_in_date <= $2)
+			  ORDER BY id`
+	return r.selectBookings(ctx, op, query, ttl.Seconds(), checkInBefore)
+}
+
+func (r *bookingRepository) GetByIdForUpdateWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Booking, error) {
+	const op = "bookingRepository.GetByIdForUpdateWithTx"
+	query := `SELECT * FROM bookings WHERE id = $1 FOR UPDATE`
+	booking := &models.Booking{}
+	if err := tx.QueryRowxContext(ctx, query, id).StructScan(booking); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return booking, nil
+}
+
+func (r *bookingRepository) UpdateStatusWithTx(ctx context.Context, id int64, status string, tx *sqlx.Tx) (*models.Booking, error) {
+	const op = "bookingRepository.UpdateStatusWithTx"
+	query := `UPDATE bookings SET status = $1 WHERE id = $2 RETURNING *`
+	booking := &models.Booking{}
+	if err := tx.QueryRowxContext(ctx, query, status, id).StructScan(booking); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return booking, nil
+}
+
+func (r *bookingRepository) SaveStatusChangeWithTx(ctx context.Context, change *models.BookingStatusChange, tx *sqlx.Tx) error {
+	const op = "bookingRepository.SaveStatusChangeWithTx"
+	query := `INSERT INTO booking_status_history (booking_id, from_status, to_status, changed_by, reason)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING *`
+	if err := tx.QueryRowxContext(ctx, query, change.BookingId, change.FromStatus, change.ToStatus,
+		change.ChangedBy, change.Reason).StructScan(change); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *bookingRepository) GetStatusHistory(ctx context.Context, bookingId int64) ([]*models.BookingStatusChange, error) {
+	const op = "bookingRepository.GetStatusHistory"
+	query := `SELECT * FROM booking_status_history WHERE booking_id = $1 ORDER BY changed_at, id`
+	rows, err := r.Db.QueryxContext(ctx, query, bookingId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	history := []*models.BookingStatusChange{}
+	for rows.Next() {
+		change := &models.BookingStatusChange{}
+		if err := rows.StructScan(change); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return history, nil
+}
+
+func (r *bookingRepository) selectBookings(ctx context.Context, op string, query string, args ...interface{}) ([]*models.Booking, error) {
+	rows, err := r.Db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	bookings := []*models.Booking{}
+	for rows.Next() {
+		booking := &models.Booking{}
+		if err := rows.StructScan(booking); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		bookings = append(bookings, booking)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bookings, nil
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+	"net/http"
+	audit "property-managment-service/internal/audit/service"
+	bookingHttp "property-managment-service/internal/booking/delivery/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	propertyHttp "property-managment-service/internal/property/delivery/http"
+	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+	StatusDeclined  = "declined"
+
+	expirationReason = "request expired"
+)
+
+type BookingRepository interface {
+	CreateWithTx(ctx context.Context, booking *models.Booking, tx *sqlx.Tx) (*models.Booking, error)
+	HasOverlapWithTx(ctx context.Context, propertyId int64, checkIn string, checkOut string, tx *sqlx.Tx) (bool, error)
+	HasBlockedDatesWithTx(ctx context.Context, propertyId int64, checkIn string, checkOut string, tx *sqlx.Tx) (bool, error)
+	GetPropertyForShareWithTx(ctx context.Context, propertyId int64, tx *sqlx.Tx) (*models.Property, error)
+	GetById(ctx context.Context, id int64) (*models.Booking, error)
+	GetByUserId(ctx context.Context, userId int64) ([]*models.Booking, error)
+	GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Booking, error)
+	GetExpiredPending(ctx context.Context, ttl time.Duration, checkInBefore string) ([]*models.Booking, error)
+	GetByIdForUpdateWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Booking, error)
+	UpdateStatusWithTx(ctx context.Context, id int64, status string, tx *sqlx.Tx) (*models.Booking, error)
+	SaveStatusChangeWithTx(ctx context.Context, change *models.BookingStatusChange, tx *sqlx.Tx) error
+	GetStatusHistory(ctx context.Context, bookingId int64) ([]*models.BookingStatusChange, error)
+}
+
+type bookingService struct {
+	log                *slog.Logger
+	bookingRepo        BookingRepository
+	propertyService    propertyHttp.PropertyService
+	transactionManager db.TransactionManager
+	pendingTTL         time.Duration
+	audit              *audit.AuditService
+}
+
+func NewBookingService(
+	bookingRepo BookingRepository,
+	propertyService propertyHttp.PropertyService,
+	transactionManager db.TransactionManager,
+	pendingTTL time.Duration,
+	audit *audit.AuditService,
+	log *slog.Logger,
+) bookingHttp.BookingService {
+	return &bookingService{
+		log:                log,
+		bookingRepo:        bookingRepo,
+		propertyService:    propertyService,
+		transactionManager: transactionManager,
+		pendingTTL:         pendingTTL,
+		audit:              audit,
+	}
+}
+
+func (s *bookingService) Create(ctx context.Context, userId int64, req *request.CreateBookingRequest) (*models.Booking, error) {
+	checkIn, err := time.Parse(dateLayout, req.CheckInDate)
+	if err != nil {
+		return nil, httpErrors.NewBadRequestError("invalid checkInDate")
+	}
+	checkOut, err := time.Parse(dateLayout, req.CheckOutDate)
+	if err != nil {
+		return nil, httpErrors.NewBadRequestError("invalid checkOutDate")
+	}
+
+	nights := int(checkOut.Sub(checkIn).Hours() / 24)
+	if nights <= 0 {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "checkOutDate must be after checkInDate", nil)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if checkIn.Before(today) {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "checkInDate must not be in the past", nil)
+	}
+
+	property, err := s.propertyService.GetById(ctx, req.PropertyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if property.OwnerId == userId {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "owner can't book own property", nil)
+	}
+
+	booking := &models.Booking{
+		PropertyId:   property.ID,
+		UserId:       userId,
+		CheckInDate:  req.CheckInDate,
+		CheckOutDate: req.CheckOutDate,
+		TotalPrice:   property.Price * nights,
+		Status:       StatusPending,
+		CreatedAt:    time.Now().Format(dateLayout),
+	}
+
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := s.checkBookable(ctx, booking.PropertyId, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	blocked, err := s.bookingRepo.HasBlockedDatesWithTx(ctx, booking.PropertyId, booking.CheckInDate, booking.CheckOutDate, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	overlaps, err := s.bookingRepo.HasOverlapWithTx(ctx, booking.PropertyId, booking.CheckInDate, booking.CheckOutDate, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if blocked || overlaps {
+		tx.Rollback()
+		return nil, httpErrors.NewRestErrorFrom(http.StatusConflict, httpErrors.DatesUnavailable, nil)
+	}
+
+	// Параллельные заявки на те же даты отсекает ограничение bookings_no_overlap (SQLSTATE 23P01)
+	booking, err = s.bookingRepo.CreateWithTx(ctx, booking, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionCreate, models.AuditEntityBooking, booking.Id, nil, formatDates(booking)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return formatDates(booking), nil
+}
+
+func (s *bookingService) GetById(ctx context.Context, id int64) (*models.Booking, error) {
+	booking, err := s.bookingRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return formatDates(booking), nil
+}
+
+func (s *bookingService) GetByUserId(ctx context.Context, userId int64) ([]*models.Booking, error) {
+	bookings, err := s.bookingRepo.GetByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, booking := range bookings {
+		formatDates(booking)
+	}
+	return bookings, nil
+}
+
+func (s *bookingService) GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Booking, error) {
+	bookings, err := s.bookingRepo.GetByPropertyId(ctx, propertyId)
+	if err != nil {
+		return nil, err
+	}
+	for _, booking := range bookings {
+		formatDates(booking)
+	}
+	return bookings, nil
+}
+
+func (s *bookingService) Confirm(ctx context.Context, id int64, userId int64, reason string) (*models.Booking, error) {
+	return s.transition(ctx, id, &userId, StatusConfirmed, reason)
+}
+
+func (s *bookingService) Decline(ctx context.Context, id int64, userId int64, reason string) (*models.Booking, error) {
+	return s.transition(ctx, id, &userId, StatusDeclined, reason)
+}
+
+func (s *bookingService) Cancel(ctx context.Context, id int64, userId int64, reason string) (*models.Booking, error) {
+	return s.transition(ctx, id, &userId, StatusCancelled, reason)
+}
+
+func (s *bookingService) GetStatusHistory(ctx context.Context, id int64) ([]*models.BookingStatusChange, error) {
+	if _, err := s.bookingRepo.GetById(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.bookingRepo.GetStatusHistory(ctx, id)
+}
+
+// ExpirePending отклоняет заявки, которые владелец не обработал за pendingTTL или до даты заезда
+func (s *bookingService) ExpirePending(ctx context.Context) (int, error) {
+	bookings, err := s.bookingRepo.GetExpiredPending(ctx, s.pendingTTL, time.Now().Format(dateLayout))
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, booking := range bookings {
+		if _, err := s.transition(ctx, booking.Id, nil, StatusDeclined, expirationReason); err != nil {
+			s.log.Error("ExpirePending", "booking id", booking.Id, "error", err.Error())
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// transition переводит бронирование в статус to и записывает переход в историю.
+// actorId == nil означает, что переход выполняет система.
+func (s *bookingService) transition(ctx context.Context, id int64, actorId *int64, to string, reason string) (*models.Booking, error) {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	booking, err := s.bookingRepo.GetByIdForUpdateWithTx(ctx, id, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	formatDates(booking)
+
+	role, err := s.actorRole(ctx, booking, actorId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := checkTransition(booking, to, role, time.Now().UTC().Truncate(24*time.Hour)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if to == StatusConfirmed {
+		if err := s.checkBookable(ctx, booking.PropertyId, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	change := &models.BookingStatusChange{
+		BookingId:  booking.Id,
+		FromStatus: booking.Status,
+		ToStatus:   to,
+		ChangedBy:  actorId,
+		Reason:     reason,
+	}
+
+	before := *booking
+	booking, err = s.bookingRepo.UpdateStatusWithTx(ctx, id, to, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionUpdate, models.AuditEntityBooking, id, before, formatDates(booking)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.bookingRepo.SaveStatusChangeWithTx(ctx, change, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("transition", "booking id", id, "from", change.FromStatus, "to", to, "role", string(role))
+	return formatDates(booking), nil
+}
+
+// checkBookable проверяет, что объявление опубликовано и не в архиве
+func (s *bookingService) checkBookable(ctx context.Context, propertyId int64, tx *sqlx.Tx) error {
+	property, err := s.bookingRepo.GetPropertyForShareWithTx(ctx, propertyId, tx)
+	if err != nil {
+		return err
+	}
+	if property.DeletedAt != nil {
+		return httpErrors.NewConflictError("property is archived")
+	}
+	if property.Status != models.PropertyStatusPublished {
+		return httpErrors.NewConflictError("property is not published")
+	}
+	return nil
+}
+
+func (s *bookingService) actorRole(ctx context.Context, booking *models.Booking, actorId *int64) (actorRole, error) {
+	if actorId == nil {
+		return roleSystem, nil
+	}
+	if *actorId == booking.UserId {
+		return roleGuest, nil
+	}
+
+	property, err := s.propertyService.GetById(ctx, booking.PropertyId)
+	if err != nil {
+		return "", err
+	}
+	if *actorId == property.OwnerId {
+		return roleOwner, nil
+	}
+	return "", httpErrors.NewForbiddenError(nil)
+}
+
+// formatDates приводит даты, прочитанные из БД в формате RFC3339, к виду 2006-01-02
+func formatDates(booking *models.Booking) *models.Booking {
+	for _, date := range []*string{&booking.CheckInDate, &booking.CheckOutDate, &booking.CreatedAt} {
+		if formatted, err := utils.ParseDate(date); err == nil {
+			*date = formatted
+		}
+	}
+	return booking
+}
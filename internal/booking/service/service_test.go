@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	audit "property-managment-service/internal/audit/service"
+	"property-managment-service/internal/models"
+	propertyHttp "property-managment-service/internal/property/delivery/http"
+	"property-managment-service/pkg/db/dbtest"
+	"property-managment-service/pkg/httpErrors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	guestId    = 1
+	ownerId    = 10
+	strangerId = 20
+	pendingTTL = 48 * time.Hour
+)
+
+// fakeBookingRepo хранит бронирования в памяти и записывает историю переходов
+type fakeBookingRepo struct {
+	BookingRepository
+	bookings   map[int64]*models.Booking
+	expired    []int64
+	expiredTTL time.Duration
+	history    []*models.BookingStatusChange
+}
+
+func (r *fakeBookingRepo) GetByIdForUpdateWithTx(_ context.Context, id int64, _ *sqlx.Tx) (*models.Booking, error) {
+	booking, ok := r.bookings[id]
+	if !ok {
+		return nil, errors.New("booking not found")
+	}
+	copied := *booking
+	return &copied, nil
+}
+
+func (r *fakeBookingRepo) GetPropertyForShareWithTx(_ context.Context, id int64, _ *sqlx.Tx) (*models.Property, error) {
+	return &models.Property{ID: id, OwnerId: ownerId, Status: models.PropertyStatusPublished}, nil
+}
+
+func (r *fakeBookingRepo) UpdateStatusWithTx(_ context.Context, id int64, status string, _ *sqlx.Tx) (*models.Booking, error) {
+	r.bookings[id].Status = status
+	copied := *r.bookings[id]
+	return &copied, nil
+}
+
+func (r *fakeBookingRepo) SaveStatusChangeWithTx(_ context.Context, change *models.BookingStatusChange, _ *sqlx.Tx) error {
+	r.history = append(r.history, change)
+	return nil
+}
+
+func (r *fakeBookingRepo) GetExpiredPending(_ context.Context, ttl time.Duration, _ string) ([]*models.Booking, error) {
+	r.expiredTTL = ttl
+	bookings := []*models.Booking{}
+	for _, id := range r.expired {
+		bookings = append(bookings, r.bookings[id])
+	}
+	return bookings, nil
+}
+
+type fakePropertyService struct {
+	propertyHttp.PropertyService
+}
+
+func (fakePropertyService) GetById(_ context.Context, id int64) (*models.Property, error) {
+	return &models.Property{ID: id, OwnerId: ownerId, Status: models.PropertyStatusPublished}, nil
+}
+
+type fakeAuditRepo struct {
+	audit.AuditRepository
+}
+
+func (fakeAuditRepo) CreateWithTx(context.Context, *models.AuditEntry, *sqlx.Tx) error {
+	return nil
+}
+
+func newTestBookingService(repo BookingRepository) (*bookingService, *dbtest.TransactionManager) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tm := dbtest.NewTransactionManager()
+	return &bookingService{
+		log:                log,
+		bookingRepo:        repo,
+		propertyService:    fakePropertyService{},
+		transactionManager: tm,
+		pendingTTL:         pendingTTL,
+		audit:              audit.NewAuditService(fakeAuditRepo{}, log),
+	}, tm
+}
+
+func pendingBooking(id int64, checkIn time.Time) *models.Booking {
+	return &models.Booking{
+		Id:           id,
+		PropertyId:   5,
+		UserId:       guestId,
+		CheckInDate:  checkIn.Format(dateLayout),
+		CheckOutDate: checkIn.AddDate(0, 0, 3).Format(dateLayout),
+		Status:       StatusPending,
+	}
+}
+
+// Подтвердить или отклонить заявку может только владелец объекта
+func TestConfirmAndDeclineAreOwnerOnly(t *testing.T) {
+	checkIn := time.Now().AddDate(0, 0, 10)
+	actions := map[string]func(s *bookingService, actorId int64) (*models.Booking, error){
+		StatusConfirmed: func(s *bookingService, actorId int64) (*models.Booking, error) {
+			return s.Confirm(context.Background(), 1, actorId, "")
+		},
+		StatusDeclined: func(s *bookingService, actorId int64) (*models.Booking, error) {
+			return s.Decline(context.Background(), 1, actorId, "dates are taken")
+		},
+	}
+	tests := []struct {
+		name       string
+		actorId    int64
+		wantStatus int
+	}{
+		{name: "owner", actorId: ownerId},
+		{name: "guest", actorId: guestId, wantStatus: http.StatusConflict},
+		{name: "stranger", actorId: strangerId, wantStatus: http.StatusForbidden},
+	}
+	for to, action := range actions {
+		for _, tt := range tests {
+			t.Run(to+" by "+tt.name, func(t *testing.T) {
+				repo := &fakeBookingRepo{bookings: map[int64]*models.Booking{1: pendingBooking(1, checkIn)}}
+				s, tm := newTestBookingService(repo)
+
+				booking, err := action(s, tt.actorId)
+				if tt.wantStatus != 0 {
+					var restErr httpErrors.RestErr
+					if !errors.As(err, &restErr) || restErr.Status() != tt.wantStatus {
+						t.Fatalf("err = %v, want %d", err, tt.wantStatus)
+					}
+					if repo.bookings[1].Status != StatusPending || len(repo.history) != 0 || tm.Commits() != 0 {
+						t.Errorf("booking changed: status %s, history %d, commits %d", repo.bookings[1].Status, len(repo.history), tm.Commits())
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+				if booking.Status != to || tm.Commits() != 1 {
+					t.Errorf("status = %s with %d commits, want %s and 1", booking.Status, tm.Commits(), to)
+				}
+				actor := int64(ownerId)
+				want := []*models.BookingStatusChange{{BookingId: 1, FromStatus: StatusPending, ToStatus: to, ChangedBy: &actor, Reason: repo.history[0].Reason}}
+				if !reflect.DeepEqual(repo.history, want) {
+					t.Errorf("history = %+v, want %+v", repo.history[0], want[0])
+				}
+			})
+		}
+	}
+}
+
+func TestExpirePending(t *testing.T) {
+	soon := time.Now().AddDate(0, 0, 10)
+	repo := &fakeBookingRepo{
+		bookings: map[int64]*models.Booking{
+			1: pendingBooking(1, soon),
+			// Дата заезда уже прошла, но система всё равно может отклонить заявку
+			2: pendingBooking(2, time.Now().AddDate(0, 0, -1)),
+			3: pendingBooking(3, soon),
+			4: pendingBooking(4, soon),
+		},
+		expired: []int64{1, 2, 3},
+	}
+	// Заявку 3 подтвердили между выборкой и блокировкой: её не трогаем
+	repo.bookings[3].Status = StatusConfirmed
+	s, tm := newTestBookingService(repo)
+
+	expired, err := s.ExpirePending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 2 || tm.Commits() != 2 {
+		t.Errorf("expired = %d with %d commits, want 2 and 2", expired, tm.Commits())
+	}
+	if repo.expiredTTL != pendingTTL {
+		t.Errorf("ttl = %s, want %s", repo.expiredTTL, pendingTTL)
+	}
+
+	want := map[int64]string{1: StatusDeclined, 2: StatusDeclined, 3: StatusConfirmed, 4: StatusPending}
+	for id, status := range want {
+		if repo.bookings[id].Status != status {
+			t.Errorf("booking %d status = %s, want %s", id, repo.bookings[id].Status, status)
+		}
+	}
+	for _, change := range repo.history {
+		if change.ChangedBy != nil || change.Reason != expirationReason || change.ToStatus != StatusDeclined {
+			t.Errorf("history entry = %+v, want a system decline with reason %q", change, expirationReason)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/httpErrors"
+	"time"
+)
+
+type actorRole string
+
+const (
+	roleSystem actorRole = "system"
+	roleGuest  actorRole = "guest"
+	roleOwner  actorRole = "owner"
+)
+
+type transition struct {
+	from string
+	to   string
+}
+
+// transitionRoles описывает допустимые переходы статусов бронирования и то, кто может их выполнять
+var transitionRoles = map[transition][]actorRole{
+	{StatusPending, StatusConfirmed}:   {roleOwner},
+	{StatusPending, StatusDeclined}:    {roleOwner, roleSystem},
+	{StatusPending, StatusCancelled}:   {roleGuest},
+	{StatusConfirmed, StatusCancelled}: {roleGuest, roleOwner},
+}
+
+// checkTransition проверяет, что переход разрешён для роли на дату today.
+// Отменить или подтвердить бронирование после даты заезда нельзя.
+func checkTransition(booking *models.Booking, to string, role actorRole, today time.Time) error {
+	roles, ok := transitionRoles[transition{from: booking.Status, to: to}]
+	if !ok {
+		return transitionConflict(booking, to, fmt.Sprintf("transition from %s to %s is not allowed", booking.Status, to))
+	}
+
+	allowed := false
+	for _, r := range roles {
+		if r == role {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return transitionConflict(booking, to, fmt.Sprintf("%s can't change status from %s to %s", role, booking.Status, to))
+	}
+
+	if role != roleSystem && (to == StatusConfirmed || to == StatusCancelled) {
+		checkIn, err := time.Parse(dateLayout, booking.CheckInDate)
+		if err != nil {
+			return err
+		}
+		if !today.Before(checkIn) {
+			return transitionConflict(booking, to, "check-in date has already passed")
+		}
+	}
+
+	return nil
+}
+
+func transitionConflict(booking *models.Booking, to string, message string) error {
+	return httpErrors.NewRestError(http.StatusConflict, fmt.Sprintf("booking %d: %s", booking.Id, message), map[string]string{
+		"from": booking.Status,
+		"to":   to,
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/httpErrors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	today    = time.Date(2030, 6, 10, 0, 0, 0, 0, time.UTC)
+	statuses = []string{StatusPending, StatusConfirmed, StatusCancelled, StatusDeclined}
+	roles    = []actorRole{roleGuest, roleOwner, roleSystem}
+)
+
+func TestCheckTransition(t *testing.T) {
+	// Разрешённые переходы до даты заезда; всё, чего здесь нет, должно давать 409
+	allowed := map[transition][]actorRole{
+		{StatusPending, StatusConfirmed}:   {roleOwner},
+		{StatusPending, StatusDeclined}:    {roleOwner, roleSystem},
+		{StatusPending, StatusCancelled}:   {roleGuest},
+		{StatusConfirmed, StatusCancelled}: {roleGuest, roleOwner},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			for _, role := range roles {
+				want := false
+				for _, r := range allowed[transition{from, to}] {
+					want = want || r == role
+				}
+
+				booking := &models.Booking{Id: 7, Status: from, CheckInDate: "2030-06-20"}
+				err := checkTransition(booking, to, role, today)
+				if want && err != nil {
+					t.Errorf("%s: %s -> %s: unexpected error %v", role, from, to, err)
+				}
+				if !want {
+					assertConflict(t, err, from, to)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckTransitionAfterCheckIn(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		role    actorRole
+		checkIn string
+		wantErr bool
+	}{
+		{name: "confirm the day before check-in", from: StatusPending, to: StatusConfirmed, role: roleOwner, checkIn: "2030-06-11"},
+		{name: "confirm on check-in day", from: StatusPending, to: StatusConfirmed, role: roleOwner, checkIn: "2030-06-10", wantErr: true},
+		{name: "confirm after check-in", from: StatusPending, to: StatusConfirmed, role: roleOwner, checkIn: "2030-06-01", wantErr: true},
+		{name: "guest cancels pending on check-in day", from: StatusPending, to: StatusCancelled, role: roleGuest, checkIn: "2030-06-10", wantErr: true},
+		{name: "guest cancels confirmed after check-in", from: StatusConfirmed, to: StatusCancelled, role: roleGuest, checkIn: "2030-06-01", wantErr: true},
+		{name: "owner cancels confirmed after check-in", from: StatusConfirmed, to: StatusCancelled, role: roleOwner, checkIn: "2030-06-01", wantErr: true},
+		// Отклонить заявку можно и после даты заезда: так истекают необработанные заявки
+		{name: "owner declines after check-in", from: StatusPending, to: StatusDeclined, role: roleOwner, checkIn: "2030-06-01"},
+		{name: "system declines after check-in", from: StatusPending, to: StatusDeclined, role: roleSystem, checkIn: "2030-06-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := &models.Booking{Id: 7, Status: tt.from, CheckInDate: tt.checkIn}
+			err := checkTransition(booking, tt.to, tt.role, today)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			assertConflict(t, err, tt.from, tt.to)
+		})
+	}
+}
+
+func TestCheckTransitionInvalidCheckIn(t *testing.T) {
+	booking := &models.Booking{Id: 7, Status: StatusPending, CheckInDate: "20.06.2030"}
+	err := checkTransition(booking, StatusConfirmed, roleOwner, today)
+	var restErr httpErrors.RestErr
+	if err == nil || errors.As(err, &restErr) {
+		t.Errorf("err = %v, want a date parse error", err)
+	}
+}
+
+// assertConflict проверяет форму ошибки перехода: 409 с исходным и целевым статусом в causes
+func assertConflict(t *testing.T, err error, from string, to string) {
+	t.Helper()
+	var restErr httpErrors.RestErr
+	if !errors.As(err, &restErr) {
+		t.Errorf("%s -> %s: err = %v, want RestErr", from, to, err)
+		return
+	}
+	if restErr.Status() != http.StatusConflict || restErr.Code() != httpErrors.CodeConflict {
+		t.Errorf("%s -> %s: status %d, code %s, want 409 %s", from, to, restErr.Status(), restErr.Code(), httpErrors.CodeConflict)
+	}
+	if want := map[string]string{"from": from, "to": to}; !reflect.DeepEqual(restErr.Causes(), want) {
+		t.Errorf("%s -> %s: causes = %v, want %v", from, to, restErr.Causes(), want)
+	}
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"property-managment-service/internal/booking/delivery/http"
+	"property-managment-service/lib/sl"
+	"time"
+)
+
+// ExpirationWorker периодически отклоняет просроченные заявки на бронирование
+type ExpirationWorker struct {
+	bookingService http.BookingService
+	interval       time.Duration
+	log            *slog.Logger
+}
+
+func NewExpirationWorker(bookingService http.BookingService, interval time.Duration, log *slog.Logger) *ExpirationWorker {
+	return &ExpirationWorker{bookingService: bookingService, interval: interval, log: log}
+}
+
+func (w *ExpirationWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.expire(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *ExpirationWorker) expire(ctx context.Context) {
+	expired, err := w.bookingService.ExpirePending(ctx)
+	if err != nil {
+		w.log.Error("ExpirationWorker", sl.Err(err))
+		return
+	}
+	if expired > 0 {
+		w.log.Info("ExpirationWorker", "expired bookings", expired)
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"os"
+	"time"
 )
 
 type Config struct {
-	App      AppConfig      `yaml:"app"`
-	Server   ServerConfig   `yaml:"server"`
-	Postgres PostgresConfig `yaml:"postgres"`
+	App        AppConfig        `yaml:"app"`
+	Server     ServerConfig     `yaml:"server"`
+	Postgres   PostgresConfig   `yaml:"postgres"`
+	Booking    BookingConfig    `yaml:"booking"`
+	Properties PropertiesConfig `yaml:"properties"`
+	Geocoding  GeocodingConfig  `yaml:"geocoding"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Images     ImagesConfig     `yaml:"images"`
+	Auth       AuthConfig       `yaml:"auth"`
 }
 
 type AppConfig struct {
@@ -35,6 +42,94 @@ type PostgresConfig struct {
 	SslMode  bool   `yaml:"sslMode"`
 }
 
+type BookingConfig struct {
+	PendingTTL         time.Duration `yaml:"pending_ttl" env-default:"48h"`
+	ExpirationInterval time.Duration `yaml:"expiration_interval" env-default:"10m"`
+}
+
+type PropertiesConfig struct {
+	// MinImages - сколько изображений нужно, чтобы отправить объявление на проверку
+	MinImages int                 `yaml:"min_images" env-default:"3"`
+	Purge     PropertyPurgeConfig `yaml:"purge"`
+}
+
+// PropertyPurgeConfig - окончательное удаление объявлений, которые пролежали в архиве дольше Retention
+type PropertyPurgeConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval" env-default:"24h"`
+	Retention time.Duration `yaml:"retention" env-default:"2160h"`
+}
+
+type GeocodingConfig struct {
+	// Provider: none - координаты только от клиента, fixture - адреса из файла FixturesPath
+	Provider     string `yaml:"provider" env-default:"none"`
+	FixturesPath string `yaml:"fixtures_path"`
+}
+
+type StorageConfig struct {
+	// Backend: local - файловая система, s3 - S3-совместимое хранилище (AWS S3, MinIO)
+	Backend string             `yaml:"backend" env-default:"local"`
+	Local   LocalStorageConfig `yaml:"local"`
+	S3      S3StorageConfig    `yaml:"s3"`
+}
+
+type LocalStorageConfig struct {
+	Root      string `yaml:"root" env-default:"./data/images"`
+	PublicURL string `yaml:"public_url"`
+}
+
+type S3StorageConfig struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"S3_SECRET_KEY"`
+	UseSSL    bool   `yaml:"use_ssl"`
+	// PublicURL - адрес бакета для прямых ссылок; если пуст, выдаются подписанные ссылки на PresignTTL
+	PublicURL  string        `yaml:"public_url"`
+	PresignTTL time.Duration `yaml:"presign_ttl" env-default:"15m"`
+}
+
+type ImagesConfig struct {
+	// MaxPixels защищает от decompression bomb: ширина*высота исходного изображения
+	MaxPixels           int64         `yaml:"max_pixels" env-default:"40000000"`
+	MaxPerProperty      int           `yaml:"max_per_property" env-default:"30"`
+	MaxBytesPerProperty int64         `yaml:"max_bytes_per_property" env-default:"209715200"`
+	GC                  ImageGCConfig `yaml:"gc"`
+}
+
+// ImageGCConfig - фоновая сверка хранилища изображений с БД
+type ImageGCConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval" env-default:"24h"`
+	// GracePeriod защищает файлы загрузок, транзакция которых ещё не завершилась
+	GracePeriod time.Duration `yaml:"grace_period" env-default:"1h"`
+	// DryRun - только отчёт в логе, без удаления файлов и записей
+	DryRun bool `yaml:"dry_run" env-default:"true"`
+}
+
+// AuthConfig - проверка JWT. HMAC-токены подписываются Server.JwtSecretKey,
+// RS256/ES256 - ключами из JWKS (файл или endpoint сервиса авторизации).
+type AuthConfig struct {
+	JWKS JWKSConfig `yaml:"jwks"`
+	// Issuer и Audience проверяются, если заданы
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// RequireExp отклоняет токены без exp
+	RequireExp bool `yaml:"require_exp"`
+	// Leeway - допустимое расхождение часов при проверке exp и nbf
+	Leeway time.Duration `yaml:"leeway" env-default:"30s"`
+}
+
+type JWKSConfig struct {
+	// File и URL взаимоисключающие; если оба пусты, принимаются только HMAC-токены
+	File string `yaml:"file"`
+	URL  string `yaml:"url"`
+	// CacheTTL - как долго ключи используются без перечитывания источника
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"1h"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"5s"`
+}
+
 func LoadConfig() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -0,0 +1,66 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"property-managment-service/internal/models"
+	"strings"
+)
+
+var ErrNotFound = errors.New("address not found")
+
+// Geocoder определяет координаты по текстовому адресу
+type Geocoder interface {
+	Geocode(ctx context.Context, address string) (*models.Coordinates, error)
+}
+
+type noopGeocoder struct{}
+
+// NewNoopGeocoder возвращает геокодер, который не знает ни одного адреса
+func NewNoopGeocoder() Geocoder {
+	return noopGeocoder{}
+}
+
+func (noopGeocoder) Geocode(ctx context.Context, address string) (*models.Coordinates, error) {
+	return nil, ErrNotFound
+}
+
+type fixtureGeocoder struct {
+	addresses map[string]models.Coordinates
+}
+
+// NewFixtureGeocoder читает JSON-файл вида {"адрес": {"latitude": 55.75, "longitude": 37.61}}.
+// Адреса сравниваются без учёта регистра и пробелов по краям.
+func NewFixtureGeocoder(path string) (Geocoder, error) {
+	const op = "geocoding.NewFixtureGeocoder"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fixtures := map[string]models.Coordinates{}
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	addresses := make(map[string]models.Coordinates, len(fixtures))
+	for address, coordinates := range fixtures {
+		addresses[normalize(address)] = coordinates
+	}
+	return &fixtureGeocoder{addresses: addresses}, nil
+}
+
+func (g *fixtureGeocoder) Geocode(ctx context.Context, address string) (*models.Coordinates, error) {
+	coordinates, ok := g.addresses[normalize(address)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &coordinates, nil
+}
+
+func normalize(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
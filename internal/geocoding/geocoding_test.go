@@ -0,0 +1,72 @@
+package geocoding
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"property-managment-service/internal/models"
+	"testing"
+)
+
+func TestFixtureGeocoder(t *testing.T) {
+	g, err := NewFixtureGeocoder(filepath.Join("testdata", "addresses.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		address string
+		want    *models.Coordinates
+	}{
+		{address: "Москва, Красная площадь, 1", want: &models.Coordinates{Latitude: 55.753930, Longitude: 37.620795}},
+		// Регистр и пробелы по краям не важны ни в запросе, ни в файле
+		{address: "  МОСКВА, красная площадь, 1 ", want: &models.Coordinates{Latitude: 55.753930, Longitude: 37.620795}},
+		{address: "sochi, kurortny prospekt 50", want: &models.Coordinates{Latitude: 43.5855, Longitude: 39.7231}},
+		{address: "Москва, Красная площадь, 2"},
+		{address: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, err := g.Geocode(context.Background(), tt.address)
+			if tt.want == nil {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("err = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != *tt.want {
+				t.Errorf("Geocode = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestFixtureGeocoderInvalidFile(t *testing.T) {
+	for _, name := range []string{"invalid.json", "missing.json"} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewFixtureGeocoder(filepath.Join("testdata", name)); err == nil {
+				t.Error("NewFixtureGeocoder succeeded, want error")
+			}
+		})
+	}
+}
+
+// Фикстуры локального окружения должны оставаться валидными
+func TestLocalFixtures(t *testing.T) {
+	g, err := NewFixtureGeocoder(filepath.Join("..", "..", "config", "geocoding.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Geocode(context.Background(), "Москва, Арбат, 10"); err != nil {
+		t.Errorf("Geocode: %v", err)
+	}
+}
+
+func TestNoopGeocoder(t *testing.T) {
+	if _, err := NewNoopGeocoder().Geocode(context.Background(), "Москва, Арбат, 10"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
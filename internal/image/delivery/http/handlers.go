@@ -2,29 +2,34 @@ package http
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
+	"io"
 	"log/slog"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"property-managment-service/internal/config"
+	"property-managment-service/internal/image/storage"
 	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
 	"property-managment-service/pkg/httpErrors"
 	"property-managment-service/pkg/utils"
 	"strconv"
 )
 
 type ImageService interface {
-	UploadImage(ctx context.Context, file *multipart.FileHeader, propertyId int64) error
-	GetImage(ctx context.Context, id int64) (string, *os.File, error)
+	UploadImages(ctx context.Context, propertyId int64, files []*multipart.FileHeader) ([]models.Image, error)
+	GetImage(ctx context.Context, id int64, size string) (string, io.ReadCloser, error)
 	GetImagesByPropertyId(ctx context.Context, propertyId int64) ([]models.Image, error)
-	UploadImageFromBase64(ctx context.Context, base64Image string, propertyId int64, tx *sqlx.Tx) error
-	UploadImagesFromBase64(ctx context.Context, base64Images []string, propertyId int64, tx *sqlx.Tx) error
-	DeleteImagesByPropertyId(ctx context.Context, propertyId int64, tx *sqlx.Tx) error
+	UploadImageFromBase64(ctx context.Context, base64Image string, propertyId int64, tx *sqlx.Tx) (*models.Image, error)
+	UploadImagesFromBase64(ctx context.Context, base64Images []string, propertyId int64, tx *sqlx.Tx) ([]models.Image, error)
+	DeleteImage(ctx context.Context, imageId int64) error
+	DeleteImagesByPropertyId(ctx context.Context, propertyId int64, tx *sqlx.Tx) ([]models.Image, error)
+	RemoveImageFiles(ctx context.Context, images []models.Image)
+	ReorderImages(ctx context.Context, propertyId int64, imageIds []int64) ([]models.Image, error)
+	SetCoverImage(ctx context.Context, propertyId int64, imageId int64) error
+	UpdateCaption(ctx context.Context, imageId int64, caption string) (*models.Image, error)
 }
 
 type imageHandlers struct {
@@ -37,29 +42,53 @@ func NewImageHandlers(cfg *config.Config, service ImageService, log *slog.Logger
 	return &imageHandlers{cfg: cfg, log: log, imageService: service}
 }
 
+// UploadImage принимает один или несколько файлов в полях images (и image для совместимости)
 func (h *imageHandlers) UploadImage() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling UploadImage", slog.String("request_id", requestID))
 		propertyIdStr := c.FormValue("propertyId")
 		if propertyIdStr == "" {
-			return c.JSON(http.StatusBadRequest, "Отсутствует propertyId")
+			return httpErrors.NewBadRequestError("propertyId is required")
 		}
 
 		propertyId, err := strconv.ParseInt(propertyIdStr, 10, 64)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, "Invalid propertyId")
+			return httpErrors.NewBadRequestError("invalid propertyId")
 		}
 
-		file, err := c.FormFile("image")
+		form, err := c.MultipartForm()
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, "Не удалось получить файл")
+			return httpErrors.NewBadRequestError("invalid multipart form")
 		}
-		err = h.imageService.UploadImage(ctx, file, propertyId)
+		files := append(form.File["images"], form.File["image"]...)
+		if len(files) == 0 {
+			return httpErrors.NewBadRequestError("at least one file is required")
+		}
+
+		images, err := h.imageService.UploadImages(ctx, propertyId, files)
 		if err != nil {
-			fmt.Println(err)
-			return c.JSON(http.StatusInternalServerError, "internal error")
+			return err
 		}
-		return c.JSON(http.StatusOK, "success")
+		return c.JSON(http.StatusCreated, images)
+	}
+}
+
+func (h *imageHandlers) DeleteImage() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling DeleteImage", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		if err := h.imageService.DeleteImage(ctx, id); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
 	}
 }
 
@@ -68,14 +97,14 @@ func (h *imageHandlers) GetImage() echo.HandlerFunc {
 		ctx := utils.GetRequestCtx(c)
 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
-			return c.String(http.StatusBadRequest, "Invalid ID")
+			return httpErrors.NewBadRequestError("invalid id")
 		}
-		mimeType, file, err := h.imageService.GetImage(ctx, id)
+		mimeType, file, err := h.imageService.GetImage(ctx, id, c.QueryParam("size"))
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				return c.String(http.StatusNotFound, "Image not found")
+			if errors.Is(err, storage.ErrNotFound) {
+				return httpErrors.NewNotFoundError("image not found")
 			}
-			return c.String(http.StatusInternalServerError, "Error fetching image")
+			return err
 		}
 		defer file.Close()
 		return c.Stream(http.StatusOK, mimeType, file)
@@ -87,12 +116,80 @@ func (h *imageHandlers) GetImageByPropertyId() echo.HandlerFunc {
 		ctx := utils.GetRequestCtx(c)
 		id, err := strconv.ParseInt(c.QueryParam("propertyId"), 10, 64)
 		if err != nil {
-			return c.String(http.StatusBadRequest, "Invalid ID")
+			return httpErrors.NewBadRequestError("invalid propertyId")
 		}
 		images, err := h.imageService.GetImagesByPropertyId(ctx, id)
 		if err != nil {
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
+		}
+		return c.JSON(http.StatusOK, images)
+	}
+}
+
+func (h *imageHandlers) ReorderImages() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling ReorderImages", slog.String("request_id", requestID))
+		propertyId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		r := &request.ReorderImagesRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return httpErrors.NewBadRequestError(err)
+		}
+
+		images, err := h.imageService.ReorderImages(ctx, propertyId, r.ImageIds)
+		if err != nil {
+			return err
 		}
 		return c.JSON(http.StatusOK, images)
 	}
 }
+
+func (h *imageHandlers) SetCoverImage() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling SetCoverImage", slog.String("request_id", requestID))
+		propertyId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		r := &request.SetCoverImageRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return httpErrors.NewBadRequestError(err)
+		}
+
+		if err := h.imageService.SetCoverImage(ctx, propertyId, r.ImageId); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func (h *imageHandlers) UpdateCaption() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling UpdateCaption", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		r := &request.ImageCaptionRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return httpErrors.NewBadRequestError(err)
+		}
+
+		image, err := h.imageService.UpdateCaption(ctx, id, r.Caption)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, image)
+	}
+}
@@ -2,17 +2,34 @@ package http
 
 import (
 	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
 	"property-managment-service/internal/middleware"
 )
 
 type ImageHandlers interface {
 	UploadImage() echo.HandlerFunc
+	DeleteImage() echo.HandlerFunc
 	GetImage() echo.HandlerFunc
 	GetImageByPropertyId() echo.HandlerFunc
+	ReorderImages() echo.HandlerFunc
+	SetCoverImage() echo.HandlerFunc
+	UpdateCaption() echo.HandlerFunc
 }
 
 func MapImageRoutes(imageGroup *echo.Group, h ImageHandlers, mw *middleware.MiddlewareManager) {
-	imageGroup.POST("", h.UploadImage())
+	imageGroup.POST("", h.UploadImage(), mw.AuthJWTMiddleware(),
+		mw.RequireOwner(authz.ResourceProperty, middleware.FormValue("propertyId")))
+	imageGroup.DELETE("/:id", h.DeleteImage(), mw.AuthJWTMiddleware(),
+		mw.RequireOwner(authz.ResourceImage, middleware.PathParam("id")))
 	imageGroup.GET("/:id", h.GetImage())
 	imageGroup.GET("", h.GetImageByPropertyId())
+	imageGroup.PUT("/:id/caption", h.UpdateCaption(), mw.AuthJWTMiddleware(),
+		mw.RequireOwner(authz.ResourceImage, middleware.PathParam("id")))
+}
+
+// MapPropertyImageRoutes - операции над галереей объекта целиком
+func MapPropertyImageRoutes(propertyGroup *echo.Group, h ImageHandlers, mw *middleware.MiddlewareManager) {
+	owner := mw.RequireOwner(authz.ResourceProperty, middleware.PathParam("id"))
+	propertyGroup.PUT("/:id/images/order", h.ReorderImages(), mw.AuthJWTMiddleware(), owner)
+	propertyGroup.PUT("/:id/images/cover", h.SetCoverImage(), mw.AuthJWTMiddleware(), owner)
 }
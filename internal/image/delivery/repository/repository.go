@@ -8,6 +8,15 @@ import (
 	"property-managment-service/internal/models"
 )
 
+// insertQuery ставит новое изображение в конец галереи; первое изображение объекта становится обложкой
+const insertQuery = `INSERT INTO properties_images (property_id, image_url, width, height, bytes, variants, position, is_cover)
+	VALUES ($1, $2, $3, $4, $5, $6,
+	        (SELECT COALESCE(MAX(position) + 1, 0) FROM properties_images WHERE property_id = $1),
+	        NOT EXISTS (SELECT 1 FROM properties_images WHERE property_id = $1 AND is_cover))
+	RETURNING *`
+
+const usageQuery = `SELECT COUNT(*) AS count, COALESCE(SUM(bytes), 0) AS bytes FROM properties_images WHERE property_id = $1`
+
 type imageRepository struct {
 	Db *sqlx.DB
 }
@@ -18,8 +27,8 @@ func NewImageRepository(db *sqlx.DB) service2.ImageRepository {
 
 func (r *imageRepository) SaveImage(ctx context.Context, image *models.Image) (*models.Image, error) {
 	const op = "imageRepository.SaveImage"
-	query := `INSERT INTO properties_images (property_id, image_url) VALUES ($1, $2) RETURNING *`
-	if err := r.Db.QueryRowxContext(ctx, query, image.PropertyId, image.ImageUrl).StructScan(image); err != nil {
+	query := insertQuery
+	if err := r.Db.QueryRowxContext(ctx, query, image.PropertyId, image.ImageUrl, image.Width, image.Height, image.Bytes, image.Variants).StructScan(image); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return image, nil
@@ -37,24 +46,10 @@ func (r *imageRepository) GetImage(ctx context.Context, id int64) (*models.Image
 
 func (r *imageRepository) GetImagesByPropertyID(ctx context.Context, propertyID int64) ([]models.Image, error) {
 	const op = "imageRepository.GetImagesByPropertyID"
-	query := `SELECT * FROM properties_images WHERE property_id = $1`
-	rows, err := r.Db.QueryContext(ctx, query, propertyID)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	defer rows.Close()
+	query := `SELECT * FROM properties_images WHERE property_id = $1 ORDER BY position, id`
 
 	var images []models.Image
-	for rows.Next() {
-		var image models.Image
-		err := rows.Scan(&image.Id, &image.PropertyId, &image.ImageUrl)
-		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
-		images = append(images, image)
-	}
-
-	if err := rows.Err(); err != nil {
+	if err := r.Db.SelectContext(ctx, &images, query, propertyID); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -63,10 +58,10 @@ func (r *imageRepository) GetImagesByPropertyID(ctx context.Context, propertyID
 
 func (r *imageRepository) SaveImageWithTx(ctx context.Context, image *models.Image, tx *sqlx.Tx) (*models.Image, error) {
 	const op = "imageRepository.SaveImage"
-	query := `INSERT INTO properties_images (property_id, image_url) VALUES ($1, $2) RETURNING *`
+	query := insertQuery
 
 	// Используем переданную транзакцию
-	if err := tx.QueryRowxContext(ctx, query, image.PropertyId, image.ImageUrl).StructScan(image); err != nil {
+	if err := tx.QueryRowxContext(ctx, query, image.PropertyId, image.ImageUrl, image.Width, image.Height, image.Bytes, image.Variants).StructScan(image); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return image, nil
@@ -84,3 +79,115 @@ func (r *imageRepository) DeleteWithTx(ctx context.Context, id int64, tx *sqlx.T
 	}
 	return nil
 }
+
+func (r *imageRepository) GetUsage(ctx context.Context, propertyID int64) (*models.ImageUsage, error) {
+	const op = "imageRepository.GetUsage"
+	usage := &models.ImageUsage{}
+	if err := r.Db.GetContext(ctx, usage, usageQuery, propertyID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return usage, nil
+}
+
+func (r *imageRepository) GetUsageWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) (*models.ImageUsage, error) {
+	const op = "imageRepository.GetUsageWithTx"
+	usage := &models.ImageUsage{}
+	if err := tx.GetContext(ctx, usage, usageQuery, propertyID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return usage, nil
+}
+
+// LockIdsWithTx блокирует изображения объекта до конца транзакции и возвращает их id
+// LockPropertyWithTx блокирует строку объекта до конца транзакции: загрузки в один объект
+// проверяют квоту по очереди. sql.ErrNoRows - объекта нет.
+func (r *imageRepository) LockPropertyWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) error {
+	const op = "imageRepository.LockPropertyWithTx"
+	query := `SELECT id FROM properties WHERE id = $1 FOR UPDATE`
+	var id int64
+	if err := tx.QueryRowxContext(ctx, query, propertyID).Scan(&id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *imageRepository) LockIdsWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) ([]int64, error) {
+	const op = "imageRepository.LockIdsWithTx"
+	query := `SELECT id FROM properties_images WHERE property_id = $1 ORDER BY id FOR UPDATE`
+	ids := []int64{}
+	if err := tx.SelectContext(ctx, &ids, query, propertyID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// ReorderWithTx выставляет position по порядку imageIDs
+func (r *imageRepository) ReorderWithTx(ctx context.Context, propertyID int64, imageIDs []int64, tx *sqlx.Tx) error {
+	const op = "imageRepository.ReorderWithTx"
+	query := `UPDATE properties_images i
+			  SET position = o.ord - 1
+			  FROM unnest($2::bigint[]) WITH ORDINALITY AS o(id, ord)
+			  WHERE i.id = o.id AND i.property_id = $1`
+	if _, err := tx.ExecContext(ctx, query, propertyID, imageIDs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetCoverWithTx снимает признак обложки с текущей и ставит его на imageID.
+// Два запроса, так как уникальный индекс обложки проверяется построчно.
+func (r *imageRepository) SetCoverWithTx(ctx context.Context, propertyID int64, imageID int64, tx *sqlx.Tx) error {
+	const op = "imageRepository.SetCoverWithTx"
+	query := `UPDATE properties_images SET is_cover = FALSE WHERE property_id = $1 AND is_cover`
+	if _, err := tx.ExecContext(ctx, query, propertyID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `UPDATE properties_images SET is_cover = TRUE WHERE id = $1 AND property_id = $2 RETURNING id`
+	var id int64
+	if err := tx.QueryRowxContext(ctx, query, imageID, propertyID).Scan(&id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *imageRepository) UpdateCaptionWithTx(ctx context.Context, id int64, caption string, tx *sqlx.Tx) (*models.Image, error) {
+	const op = "imageRepository.UpdateCaptionWithTx"
+	query := `UPDATE properties_images SET caption = $1 WHERE id = $2 RETURNING *`
+	image := &models.Image{}
+	if err := tx.QueryRowxContext(ctx, query, caption, id).StructScan(image); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return image, nil
+}
+
+// PromoteCoverWithTx делает обложкой первое по порядку изображение объекта
+func (r *imageRepository) PromoteCoverWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) error {
+	const op = "imageRepository.PromoteCoverWithTx"
+	query := `UPDATE properties_images SET is_cover = TRUE
+			  WHERE id = (SELECT id FROM properties_images WHERE property_id = $1 ORDER BY position, id LIMIT 1)`
+	if _, err := tx.ExecContext(ctx, query, propertyID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *imageRepository) GetAll(ctx context.Context) ([]models.Image, error) {
+	const op = "imageRepository.GetAll"
+	query := `SELECT * FROM properties_images ORDER BY id`
+
+	var images []models.Image
+	if err := r.Db.SelectContext(ctx, &images, query); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return images, nil
+}
+
+func (r *imageRepository) UpdateVariants(ctx context.Context, id int64, variants models.ImageVariants) error {
+	const op = "imageRepository.UpdateVariants"
+	query := `UPDATE properties_images SET variants = $1 WHERE id = $2`
+	if _, err := r.Db.ExecContext(ctx, query, variants, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
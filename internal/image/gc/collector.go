@@ -0,0 +1,190 @@
+package gc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+	"property-managment-service/internal/image/storage"
+	"property-managment-service/internal/models"
+	"property-managment-service/lib/sl"
+	"property-managment-service/pkg/db"
+	"time"
+)
+
+// keyPrefix - общий префикс ключей изображений в хранилище
+const keyPrefix = "properties/"
+
+type ImageRepository interface {
+	GetAll(ctx context.Context) ([]models.Image, error)
+	DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error
+	PromoteCoverWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) error
+	UpdateVariants(ctx context.Context, id int64, variants models.ImageVariants) error
+}
+
+// MissingFile - файл, на который ссылается запись, но которого нет в хранилище.
+// Variant пуст, если отсутствует исходный файл.
+type MissingFile struct {
+	ImageId    int64  `json:"imageId"`
+	PropertyId int64  `json:"propertyId"`
+	Variant    string `json:"variant,omitempty"`
+	Key        string `json:"key"`
+}
+
+type Report struct {
+	DryRun          bool          `json:"dryRun"`
+	ScannedRows     int           `json:"scannedRows"`
+	ScannedObjects  int           `json:"scannedObjects"`
+	OrphanedObjects []string      `json:"orphanedObjects"`
+	MissingFiles    []MissingFile `json:"missingFiles"`
+	// DeletedRows - записи, удалённые из-за отсутствия исходного файла (только при DryRun = false)
+	DeletedRows []int64 `json:"deletedRows"`
+}
+
+// Collector сверяет хранилище изображений с таблицей properties_images
+type Collector struct {
+	imageRepo          ImageRepository
+	storage            storage.ImageStorage
+	transactionManager db.TransactionManager
+	gracePeriod        time.Duration
+	log                *slog.Logger
+}
+
+func NewCollector(
+	imageRepo ImageRepository,
+	storage storage.ImageStorage,
+	transactionManager db.TransactionManager,
+	gracePeriod time.Duration,
+	log *slog.Logger,
+) *Collector {
+	return &Collector{
+		imageRepo:          imageRepo,
+		storage:            storage,
+		transactionManager: transactionManager,
+		gracePeriod:        gracePeriod,
+		log:                log,
+	}
+}
+
+// Run находит файлы без записей и записи без файлов. В режиме dryRun только составляет отчёт,
+// иначе удаляет осиротевшие файлы, записи без исходного файла и отсутствующие варианты из метаданных.
+func (c *Collector) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	const op = "gc.Collector.Run"
+	report := &Report{DryRun: dryRun, OrphanedObjects: []string{}, MissingFiles: []MissingFile{}, DeletedRows: []int64{}}
+
+	// Записи читаем до листинга хранилища: файлы загрузок, начатых позже, окажутся моложе
+	// gracePeriod и не будут считаться осиротевшими
+	images, err := c.imageRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	report.ScannedRows = len(images)
+
+	referenced := make(map[string]bool)
+	for _, image := range images {
+		referenced[image.ImageUrl] = true
+		for _, variant := range image.Variants {
+			referenced[variant.Key] = true
+		}
+	}
+
+	existing := make(map[string]bool)
+	threshold := time.Now().Add(-c.gracePeriod)
+	err = c.storage.List(ctx, keyPrefix, func(object storage.ObjectInfo) error {
+		report.ScannedObjects++
+		existing[object.Key] = true
+		if !referenced[object.Key] && object.ModTime.Before(threshold) {
+			report.OrphanedObjects = append(report.OrphanedObjects, object.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, image := range images {
+		if !existing[image.ImageUrl] {
+			report.MissingFiles = append(report.MissingFiles, MissingFile{ImageId: image.Id, PropertyId: image.PropertyId, Key: image.ImageUrl})
+		}
+		for name, variant := range image.Variants {
+			if !existing[variant.Key] {
+				report.MissingFiles = append(report.MissingFiles, MissingFile{
+					ImageId: image.Id, PropertyId: image.PropertyId, Variant: name, Key: variant.Key,
+				})
+			}
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, key := range report.OrphanedObjects {
+		if err := c.storage.Delete(ctx, key); err != nil {
+			c.log.Error("failed to delete orphaned object", slog.String("key", key), sl.Err(err))
+		}
+	}
+
+	for _, image := range images {
+		if err := c.repair(ctx, image, existing, report); err != nil {
+			c.log.Error("failed to repair image", slog.Int64("image_id", image.Id), sl.Err(err))
+		}
+	}
+
+	return report, nil
+}
+
+// repair удаляет запись, если нет исходного файла, или убирает из неё отсутствующие варианты
+func (c *Collector) repair(ctx context.Context, image models.Image, existing map[string]bool, report *Report) error {
+	if !existing[image.ImageUrl] {
+		if err := c.deleteImage(ctx, image); err != nil {
+			return err
+		}
+		report.DeletedRows = append(report.DeletedRows, image.Id)
+
+		// Уцелевшие варианты удалённой записи больше никому не нужны
+		for _, variant := range image.Variants {
+			if existing[variant.Key] {
+				if err := c.storage.Delete(ctx, variant.Key); err != nil {
+					c.log.Error("failed to delete variant", slog.String("key", variant.Key), sl.Err(err))
+				}
+			}
+		}
+		return nil
+	}
+
+	variants := make(models.ImageVariants, len(image.Variants))
+	for name, variant := range image.Variants {
+		if existing[variant.Key] {
+			variants[name] = variant
+		}
+	}
+	if len(variants) == len(image.Variants) {
+		return nil
+	}
+	return c.imageRepo.UpdateVariants(ctx, image.Id, variants)
+}
+
+func (c *Collector) deleteImage(ctx context.Context, image models.Image) error {
+	tx, err := c.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := c.imageRepo.DeleteWithTx(ctx, image.Id, tx); err != nil {
+		// Запись могли удалить, пока шла сверка
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if image.IsCover {
+		if err := c.imageRepo.PromoteCoverWithTx(ctx, image.PropertyId, tx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -1,18 +1,27 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"io"
 	"log/slog"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	audit "property-managment-service/internal/audit/service"
+	"property-managment-service/internal/config"
 	http2 "property-managment-service/internal/image/delivery/http"
+	"property-managment-service/internal/image/storage"
 	"property-managment-service/internal/models"
+	"property-managment-service/lib/sl"
+	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/imaging"
 	"strings"
 )
 
@@ -22,193 +31,489 @@ type ImageRepository interface {
 	GetImage(ctx context.Context, id int64) (*models.Image, error)
 	GetImagesByPropertyID(ctx context.Context, propertyID int64) ([]models.Image, error)
 	DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error
+	GetUsage(ctx context.Context, propertyID int64) (*models.ImageUsage, error)
+	GetUsageWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) (*models.ImageUsage, error)
+	LockPropertyWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) error
+	LockIdsWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) ([]int64, error)
+	ReorderWithTx(ctx context.Context, propertyID int64, imageIDs []int64, tx *sqlx.Tx) error
+	SetCoverWithTx(ctx context.Context, propertyID int64, imageID int64, tx *sqlx.Tx) error
+	UpdateCaptionWithTx(ctx context.Context, id int64, caption string, tx *sqlx.Tx) (*models.Image, error)
+	PromoteCoverWithTx(ctx context.Context, propertyID int64, tx *sqlx.Tx) error
+	GetAll(ctx context.Context) ([]models.Image, error)
+	UpdateVariants(ctx context.Context, id int64, variants models.ImageVariants) error
 }
 
 type imageService struct {
-	log       *slog.Logger
-	imageRepo ImageRepository
+	log                *slog.Logger
+	imageRepo          ImageRepository
+	storage            storage.ImageStorage
+	transactionManager db.TransactionManager
+	limits             config.ImagesConfig
+	audit              *audit.AuditService
 }
 
-func NewImageService(imageRepo ImageRepository, log *slog.Logger) http2.ImageService {
-	return &imageService{imageRepo: imageRepo, log: log}
+func NewImageService(
+	imageRepo ImageRepository,
+	storage storage.ImageStorage,
+	transactionManager db.TransactionManager,
+	limits config.ImagesConfig,
+	audit *audit.AuditService,
+	log *slog.Logger,
+) http2.ImageService {
+	return &imageService{
+		log:                log,
+		imageRepo:          imageRepo,
+		storage:            storage,
+		transactionManager: transactionManager,
+		limits:             limits,
+		audit:              audit,
+	}
 }
 
-func (s *imageService) UploadImage(ctx context.Context, file *multipart.FileHeader, propertyId int64) error {
-	src, err := file.Open()
+// UploadImages загружает файлы в одной транзакции: либо сохраняются все, либо ни одного
+func (s *imageService) UploadImages(ctx context.Context, propertyId int64, files []*multipart.FileHeader) ([]models.Image, error) {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer src.Close()
-
-	homeDir := "/Users/roflandown/Desktop"
+	defer tx.Rollback()
 
-	uploadDir := filepath.Join(homeDir, "images")
-	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		err := os.MkdirAll(uploadDir, os.ModePerm) // Создаем директорию, если её нет
+	images := make([]models.Image, 0, len(files))
+	for _, file := range files {
+		image, err := s.uploadFile(ctx, file, propertyId, tx)
 		if err != nil {
-			return err
+			s.removeImageFiles(ctx, images)
+			return nil, err
 		}
+		images = append(images, *image)
 	}
 
-	uniqueID := uuid.New().String()
-	ext := filepath.Ext(file.Filename)
-	baseName := file.Filename[:len(file.Filename)-len(ext)]
-	newFileName := baseName + "_" + uniqueID + ext
+	if err := tx.Commit(); err != nil {
+		s.removeImageFiles(ctx, images)
+		return nil, err
+	}
+	return images, nil
+}
 
-	dstPath := filepath.Join(uploadDir, newFileName)
-	dst, err := os.Create(dstPath)
+func (s *imageService) uploadFile(ctx context.Context, file *multipart.FileHeader, propertyId int64, tx *sqlx.Tx) (*models.Image, error) {
+	src, err := file.Open()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer dst.Close()
+	defer src.Close()
 
-	_, err = dst.ReadFrom(src)
+	usage, err := s.usageWithTx(ctx, propertyId, tx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// Лимит по количеству проверяем до чтения файла; объём известен только после обработки
+	if err := s.checkQuota(usage, 0); err != nil {
+		return nil, err
 	}
 
-	image := &models.Image{PropertyId: propertyId, ImageUrl: dstPath}
-	_, err = s.imageRepo.SaveImage(ctx, image)
+	data, err := io.ReadAll(src)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return s.storeImage(ctx, propertyId, data, usage, func(image *models.Image) error {
+		if _, err := s.imageRepo.SaveImageWithTx(ctx, image, tx); err != nil {
+			return err
+		}
+		return s.audit.RecordWithTx(ctx, tx, models.AuditActionCreate, models.AuditEntityImage, image.Id, nil, image)
+	})
 }
 
-func (s *imageService) UploadImageFromBase64(ctx context.Context, base64Image string, propertyId int64, tx *sqlx.Tx) error {
-	// Разделяем Base64 строку на часть с MIME-типом и данные
+// usageWithTx читает занятое объектом место под блокировкой объекта. Без неё параллельные загрузки
+// видят одно и то же usage и вместе превышают квоту; блокировка держится до конца транзакции.
+func (s *imageService) usageWithTx(ctx context.Context, propertyId int64, tx *sqlx.Tx) (*models.ImageUsage, error) {
+	if err := s.imageRepo.LockPropertyWithTx(ctx, propertyId, tx); err != nil {
+		return nil, err
+	}
+	return s.imageRepo.GetUsageWithTx(ctx, propertyId, tx)
+}
+
+func (s *imageService) UploadImageFromBase64(ctx context.Context, base64Image string, propertyId int64, tx *sqlx.Tx) (*models.Image, error) {
+	// Префикс data:image/...;base64 не проверяем: формат определяется по содержимому файла
 	parts := strings.SplitN(base64Image, ",", 2)
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid base64 image format")
+		return nil, httpErrors.NewRestErrorFrom(http.StatusBadRequest, httpErrors.UnsupportedImage, "invalid base64 image format")
 	}
 
-	// MIME-тип (например, data:image/png;base64)
-	mimeType := parts[0]
-	imageData := parts[1]
-
 	// Декодируем Base64-строку
-	decodedImage, err := base64.StdEncoding.DecodeString(imageData)
+	decodedImage, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
-		return fmt.Errorf("failed to decode base64 image: %w", err)
+		return nil, httpErrors.NewRestErrorFrom(http.StatusBadRequest, httpErrors.UnsupportedImage, err)
 	}
 
-	// Определяем расширение файла на основе MIME-типа
-	var ext string
-	switch {
-	case strings.Contains(mimeType, "image/jpeg"):
-		ext = ".jpg"
-	case strings.Contains(mimeType, "image/png"):
-		ext = ".png"
-	case strings.Contains(mimeType, "image/gif"):
-		ext = ".gif"
-	default:
-		return fmt.Errorf("unsupported image type: %s", mimeType)
+	usage, err := s.usageWithTx(ctx, propertyId, tx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Уникальное имя файла
-	uniqueID := uuid.New().String()
-	newFileName := fmt.Sprintf("image_%s%s", uniqueID, ext)
+	return s.storeImage(ctx, propertyId, decodedImage, usage, func(image *models.Image) error {
+		if _, err := s.imageRepo.SaveImageWithTx(ctx, image, tx); err != nil {
+			return fmt.Errorf("failed to save image record: %w", err)
+		}
+		return s.audit.RecordWithTx(ctx, tx, models.AuditActionCreate, models.AuditEntityImage, image.Id, nil, image)
+	})
+}
 
-	// Путь к директории для загрузки
-	homeDir := "/Users/roflandown/Desktop"
-	uploadDir := filepath.Join(homeDir, "images")
-	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		err := os.MkdirAll(uploadDir, os.ModePerm)
+// UploadImagesFromBase64 при ошибке удаляет уже загруженные файлы: транзакцию вызывающий код откатит сам.
+// Если коммит не удастся, вызывающий код должен удалить файлы возвращённых изображений через RemoveImageFiles.
+func (s *imageService) UploadImagesFromBase64(ctx context.Context, base64Images []string, propertyId int64, tx *sqlx.Tx) ([]models.Image, error) {
+	images := make([]models.Image, 0, len(base64Images))
+	for _, base64Image := range base64Images {
+		image, err := s.UploadImageFromBase64(ctx, base64Image, propertyId, tx)
 		if err != nil {
-			return fmt.Errorf("failed to create upload directory: %w", err)
+			s.removeImageFiles(ctx, images)
+			return nil, fmt.Errorf("failed to upload image: %w", err)
 		}
+		images = append(images, *image)
 	}
+	return images, nil
+}
 
-	// Путь к файлу
-	dstPath := filepath.Join(uploadDir, newFileName)
+func (s *imageService) GetImage(ctx context.Context, id int64, size string) (string, io.ReadCloser, error) {
+	if size == "" {
+		size = imaging.SizeLarge
+	}
+	if !isKnownSize(size) {
+		return "", nil, httpErrors.NewBadRequestError(fmt.Sprintf("unknown image size %q", size))
+	}
 
-	// Записываем данные в файл
-	err = os.WriteFile(dstPath, decodedImage, os.ModePerm)
+	image, err := s.imageRepo.GetImage(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to save image: %w", err)
+		return "", nil, err
 	}
 
-	// Сохраняем информацию об изображении в базе данных
-	image := &models.Image{
-		PropertyId: propertyId,
-		ImageUrl:   dstPath,
+	// Для изображений, загруженных до появления вариантов, отдаём исходный файл
+	key := image.ImageUrl
+	if variant, ok := image.Variants[size]; ok {
+		key = variant.Key
 	}
-	_, err = s.imageRepo.SaveImageWithTx(ctx, image, tx)
+
+	object, err := s.storage.Get(ctx, key)
 	if err != nil {
-		return fmt.Errorf("failed to save image record: %w", err)
+		return "", nil, err
 	}
 
-	return nil
+	// Определяем MIME-тип по первым 512 байтам, не теряя их для последующего чтения
+	reader := bufio.NewReaderSize(object, 512)
+	buffer, err := reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		object.Close()
+		return "", nil, err
+	}
+	mimeType := http.DetectContentType(buffer)
+
+	return mimeType, readCloser{Reader: reader, Closer: object}, nil
 }
 
-func (s *imageService) UploadImagesFromBase64(ctx context.Context, base64Images []string, propertyId int64, tx *sqlx.Tx) error {
-	for _, base64Image := range base64Images {
-		err := s.UploadImageFromBase64(ctx, base64Image, propertyId, tx)
-		if err != nil {
-			return fmt.Errorf("failed to upload image: %w", err)
+func (s *imageService) GetImagesByPropertyId(ctx context.Context, propertyId int64) ([]models.Image, error) {
+	images, err := s.imageRepo.GetImagesByPropertyID(ctx, propertyId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		if images[i].Url, err = s.storage.URL(ctx, images[i].ImageUrl); err != nil {
+			return nil, err
+		}
+		for name, variant := range images[i].Variants {
+			if variant.Url, err = s.storage.URL(ctx, variant.Key); err != nil {
+				return nil, err
+			}
+			images[i].Variants[name] = variant
 		}
 	}
-	return nil
+	return images, nil
 }
 
-func (s *imageService) GetImage(ctx context.Context, id int64) (string, *os.File, error) {
-	image, err := s.imageRepo.GetImage(ctx, id)
+// DeleteImage удаляет запись и, после коммита, файлы изображения. Если удаляется обложка,
+// обложкой становится первое из оставшихся изображений.
+func (s *imageService) DeleteImage(ctx context.Context, imageId int64) error {
+	image, err := s.imageRepo.GetImage(ctx, imageId)
 	if err != nil {
-		return "", nil, err
+		return err
 	}
 
-	// Открываем файл
-	file, err := os.Open(image.ImageUrl)
+	tx, err := s.transactionManager.BeginTransaction(ctx)
 	if err != nil {
-		return "", nil, err
+		return err
 	}
+	defer tx.Rollback()
 
-	// Определяем MIME-тип файла
-	buffer := make([]byte, 512) // Буфер для чтения первых 512 байт
-	_, err = file.Read(buffer)
-	if err != nil {
-		file.Close() // Закрываем файл в случае ошибки чтения
-		return "", nil, err
+	if err := s.imageRepo.DeleteWithTx(ctx, imageId, tx); err != nil {
+		return err
 	}
-
-	// Сбрасываем указатель файла в начало для последующего использования
-	if _, err := file.Seek(0, 0); err != nil {
-		file.Close() // Закрываем файл в случае ошибки
-		return "", nil, err
+	if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionDelete, models.AuditEntityImage, imageId, image, nil); err != nil {
+		return err
+	}
+	if image.IsCover {
+		if err := s.imageRepo.PromoteCoverWithTx(ctx, image.PropertyId, tx); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
-	// Определяем MIME-тип на основе буфера
-	mimeType := http.DetectContentType(buffer)
-
-	return mimeType, file, nil
+	s.removeImageFiles(ctx, []models.Image{*image})
+	return nil
 }
 
-func (s *imageService) GetImagesByPropertyId(ctx context.Context, propertyId int64) ([]models.Image, error) {
+// DeleteImagesByPropertyId удаляет записи в транзакции вызывающего кода и возвращает удалённые изображения,
+// чтобы их файлы можно было удалить через RemoveImageFiles после коммита
+func (s *imageService) DeleteImagesByPropertyId(ctx context.Context, propertyId int64, tx *sqlx.Tx) ([]models.Image, error) {
 	images, err := s.imageRepo.GetImagesByPropertyID(ctx, propertyId)
 	if err != nil {
 		return nil, err
 	}
+	for _, image := range images {
+		if err := s.imageRepo.DeleteWithTx(ctx, image.Id, tx); err != nil {
+			return nil, err
+		}
+		if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionDelete, models.AuditEntityImage, image.Id, image, nil); err != nil {
+			return nil, err
+		}
+	}
 	return images, nil
 }
 
-func (s *imageService) DeleteImageWithTx(ctx context.Context, imageId int64, tx *sqlx.Tx) error {
-	err := s.imageRepo.DeleteWithTx(ctx, imageId, tx)
+func (s *imageService) RemoveImageFiles(ctx context.Context, images []models.Image) {
+	s.removeImageFiles(ctx, images)
+}
+
+// ReorderImages атомарно задаёт порядок галереи; imageIds должен содержать все изображения объекта
+func (s *imageService) ReorderImages(ctx context.Context, propertyId int64, imageIds []int64) ([]models.Image, error) {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	defer tx.Rollback()
+
+	existing, err := s.imageRepo.LockIdsWithTx(ctx, propertyId, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !sameIds(existing, imageIds) {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "imageIds must list every image of the property exactly once", nil)
+	}
+
+	before, err := s.imageRepo.GetImagesByPropertyID(ctx, propertyId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.imageRepo.ReorderWithTx(ctx, propertyId, imageIds, tx); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[int64]int, len(imageIds))
+	for i, id := range imageIds {
+		positions[id] = i
+	}
+	if err := s.recordChangesWithTx(ctx, tx, before, func(image *models.Image) {
+		image.Position = positions[image.Id]
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetImagesByPropertyId(ctx, propertyId)
 }
 
-func (s *imageService) DeleteImagesByPropertyId(ctx context.Context, propertyId int64, tx *sqlx.Tx) error {
-	images, err := s.GetImagesByPropertyId(ctx, propertyId)
+func (s *imageService) SetCoverImage(ctx context.Context, propertyId int64, imageId int64) error {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
 	if err != nil {
 		return err
 	}
-	for _, image := range images {
-		if err := s.DeleteImageWithTx(ctx, image.Id, tx); err != nil {
+	defer tx.Rollback()
+
+	before, err := s.imageRepo.GetImagesByPropertyID(ctx, propertyId)
+	if err != nil {
+		return err
+	}
+	if err := s.imageRepo.SetCoverWithTx(ctx, propertyId, imageId, tx); err != nil {
+		return err
+	}
+	if err := s.recordChangesWithTx(ctx, tx, before, func(image *models.Image) {
+		image.IsCover = image.Id == imageId
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *imageService) UpdateCaption(ctx context.Context, imageId int64, caption string) (*models.Image, error) {
+	before, err := s.imageRepo.GetImage(ctx, imageId)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	image, err := s.imageRepo.UpdateCaptionWithTx(ctx, imageId, caption, tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionUpdate, models.AuditEntityImage, imageId, before, image); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// recordChangesWithTx записывает в журнал изменения, которые apply вносит в изображения images;
+// изображения, которые apply не меняет, в журнал не попадают
+func (s *imageService) recordChangesWithTx(ctx context.Context, tx *sqlx.Tx, images []models.Image, apply func(image *models.Image)) error {
+	for _, before := range images {
+		after := before
+		apply(&after)
+		if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionUpdate, models.AuditEntityImage, before.Id, before, after); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+func sameIds(existing []int64, requested []int64) bool {
+	if len(existing) != len(requested) {
+		return false
+	}
+	seen := make(map[int64]bool, len(existing))
+	for _, id := range existing {
+		seen[id] = true
+	}
+	for _, id := range requested {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+	return true
+}
+
+// storeImage проверяет файл и квоту, сохраняет исходный файл и его уменьшенные копии,
+// затем вызывает save для записи в БД. Если что-то пошло не так, уже загруженные файлы удаляются.
+// Квота по объёму считается по всем сохраняемым файлам, а не по размеру загрузки.
+func (s *imageService) storeImage(ctx context.Context, propertyId int64, data []byte, usage *models.ImageUsage, save func(image *models.Image) error) (*models.Image, error) {
+	if err := s.checkQuota(usage, 0); err != nil {
+		return nil, err
+	}
+
+	if _, err := imaging.Inspect(data, s.limits.MaxPixels); err != nil {
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			return nil, httpErrors.NewRestErrorFrom(http.StatusRequestEntityTooLarge, httpErrors.ImageTooLarge, err)
+		}
+		return nil, httpErrors.NewRestErrorFrom(http.StatusBadRequest, httpErrors.UnsupportedImage, err)
+	}
+
+	// Загруженный файл не сохраняется: исходник перекодируется, как и варианты, и теряет EXIF
+	results, err := imaging.Process(data, append([]imaging.Variant{imaging.Original}, imaging.DefaultVariants...))
+	if err != nil {
+		return nil, httpErrors.NewRestErrorFrom(http.StatusBadRequest, httpErrors.UnsupportedImage, err)
+	}
+	original, results := results[0], results[1:]
+
+	total := int64(len(original.Data))
+	for _, result := range results {
+		total += int64(len(result.Data))
+	}
+	if err := s.checkQuota(usage, total); err != nil {
+		return nil, err
+	}
+
+	baseKey := fmt.Sprintf("properties/%d/%s", propertyId, uuid.New().String())
+	image := &models.Image{
+		PropertyId: propertyId,
+		ImageUrl:   baseKey + ".jpg",
+		Width:      original.Width,
+		Height:     original.Height,
+		Bytes:      total,
+		Variants:   make(models.ImageVariants, len(results)),
+	}
+
+	stored := make([]string, 0, len(results)+1)
+	if err := s.storage.Put(ctx, image.ImageUrl, bytes.NewReader(original.Data), int64(len(original.Data)), original.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+	stored = append(stored, image.ImageUrl)
+
+	for _, result := range results {
+		key := fmt.Sprintf("%s_%s.jpg", baseKey, result.Name)
+		if err := s.storage.Put(ctx, key, bytes.NewReader(result.Data), int64(len(result.Data)), result.ContentType); err != nil {
+			s.removeObjects(ctx, stored)
+			return nil, fmt.Errorf("failed to save image variant: %w", err)
+		}
+		stored = append(stored, key)
+		image.Variants[result.Name] = models.ImageVariant{
+			Key:    key,
+			Width:  result.Width,
+			Height: result.Height,
+			Bytes:  int64(len(result.Data)),
+		}
+	}
+
+	if err := save(image); err != nil {
+		s.removeObjects(ctx, stored)
+		return nil, err
+	}
+	return image, nil
+}
+
+// checkQuota проверяет, поместится ли ещё одно изображение размером size в лимиты объекта
+func (s *imageService) checkQuota(usage *models.ImageUsage, size int64) error {
+	if s.limits.MaxPerProperty > 0 && usage.Count >= s.limits.MaxPerProperty {
+		return httpErrors.NewRestErrorFrom(http.StatusRequestEntityTooLarge, httpErrors.ImageQuotaExceeded,
+			fmt.Sprintf("property already has %d of %d images", usage.Count, s.limits.MaxPerProperty))
+	}
+	if s.limits.MaxBytesPerProperty > 0 && usage.Bytes+size > s.limits.MaxBytesPerProperty {
+		return httpErrors.NewRestErrorFrom(http.StatusRequestEntityTooLarge, httpErrors.ImageQuotaExceeded,
+			fmt.Sprintf("property images would take %d of %d bytes", usage.Bytes+size, s.limits.MaxBytesPerProperty))
+	}
+	return nil
+}
+
+// removeImageFiles удаляет исходные файлы и варианты изображений. Вызывается после коммита или
+// отката транзакции, поэтому ошибки хранилища только логируются.
+func (s *imageService) removeImageFiles(ctx context.Context, images []models.Image) {
+	for _, image := range images {
+		keys := []string{image.ImageUrl}
+		for _, variant := range image.Variants {
+			keys = append(keys, variant.Key)
+		}
+		s.removeObjects(ctx, keys)
+	}
+}
+
+// removeObjects удаляет файлы по ключам; ошибки хранилища логируются, осиротевшие файлы подберёт сборщик
+func (s *imageService) removeObjects(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			s.log.Error("failed to remove orphaned image", slog.String("key", key), sl.Err(err))
+		}
+	}
+}
+
+func isKnownSize(size string) bool {
+	for _, variant := range imaging.DefaultVariants {
+		if variant.Name == size {
+			return true
+		}
+	}
+	return false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
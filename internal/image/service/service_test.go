@@ -0,0 +1,244 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"net/http"
+	audit "property-managment-service/internal/audit/service"
+	"property-managment-service/internal/config"
+	"property-managment-service/internal/image/storage"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/db/dbtest"
+	"property-managment-service/pkg/httpErrors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeImageRepo записывает порядок вызовов и отдаёт заданное usage
+type fakeImageRepo struct {
+	ImageRepository
+	usage *models.ImageUsage
+	calls []string
+}
+
+func (r *fakeImageRepo) LockPropertyWithTx(context.Context, int64, *sqlx.Tx) error {
+	r.calls = append(r.calls, "lock")
+	return nil
+}
+
+func (r *fakeImageRepo) GetUsageWithTx(context.Context, int64, *sqlx.Tx) (*models.ImageUsage, error) {
+	r.calls = append(r.calls, "usage")
+	return r.usage, nil
+}
+
+func (r *fakeImageRepo) GetImage(_ context.Context, id int64) (*models.Image, error) {
+	return &models.Image{Id: id, Caption: "old"}, nil
+}
+
+func (r *fakeImageRepo) UpdateCaptionWithTx(_ context.Context, id int64, caption string, _ *sqlx.Tx) (*models.Image, error) {
+	r.calls = append(r.calls, "caption")
+	return &models.Image{Id: id, Caption: caption}, nil
+}
+
+func (r *fakeImageRepo) SaveImageWithTx(_ context.Context, image *models.Image, _ *sqlx.Tx) (*models.Image, error) {
+	r.calls = append(r.calls, "save")
+	image.Id = int64(len(r.calls))
+	return image, nil
+}
+
+// fakeStorage хранит объекты в памяти
+type fakeStorage struct {
+	storage.ImageStorage
+	objects map[string][]byte
+	deleted []string
+}
+
+func (s *fakeStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *fakeStorage) Delete(_ context.Context, key string) error {
+	delete(s.objects, key)
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+// fakeAuditRepo имитирует журнал; err - сбой вставки записи
+type fakeAuditRepo struct {
+	audit.AuditRepository
+	err error
+}
+
+func (r fakeAuditRepo) CreateWithTx(context.Context, *models.AuditEntry, *sqlx.Tx) error {
+	return r.err
+}
+
+func newTestImageService(repo ImageRepository) *imageService {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &imageService{
+		log:                log,
+		imageRepo:          repo,
+		storage:            &fakeStorage{objects: map[string][]byte{}},
+		transactionManager: dbtest.NewTransactionManager(),
+		limits:             config.ImagesConfig{MaxPixels: 40_000_000, MaxPerProperty: 2},
+		audit:              audit.NewAuditService(fakeAuditRepo{}, log),
+	}
+}
+
+// jpegWithComment возвращает JPEG, в который после SOI вставлен сегмент COM с comment
+func jpegWithComment(t *testing.T, comment string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 48)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	segment := []byte{0xFF, 0xFE, byte((len(comment) + 2) >> 8), byte(len(comment) + 2)}
+	segment = append(segment, comment...)
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestUploadStoresReencodedOriginal(t *testing.T) {
+	repo := &fakeImageRepo{usage: &models.ImageUsage{}}
+	s := newTestImageService(repo)
+	ctx := context.Background()
+
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	const secret = "GPS 55.7558N 37.6173E"
+	data := jpegWithComment(t, secret)
+	uploaded, err := s.UploadImageFromBase64(ctx, "data:image/jpeg;base64,"+base64.StdEncoding.EncodeToString(data), 7, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects := s.storage.(*fakeStorage).objects
+	original, ok := objects[uploaded.ImageUrl]
+	if !ok {
+		t.Fatalf("original %q is not stored", uploaded.ImageUrl)
+	}
+	if bytes.Equal(original, data) {
+		t.Error("uploaded file is stored as is")
+	}
+	for key, object := range objects {
+		if bytes.Contains(object, []byte(secret)) {
+			t.Errorf("%s keeps metadata of the uploaded file", key)
+		}
+	}
+	if !strings.HasSuffix(uploaded.ImageUrl, ".jpg") || uploaded.Width != 64 || uploaded.Height != 48 {
+		t.Errorf("image = %s %dx%d", uploaded.ImageUrl, uploaded.Width, uploaded.Height)
+	}
+	var stored int64
+	for _, object := range objects {
+		stored += int64(len(object))
+	}
+	if uploaded.Bytes != stored {
+		t.Errorf("Bytes = %d, want size of all stored files %d", uploaded.Bytes, stored)
+	}
+	if len(objects) != len(uploaded.Variants)+1 {
+		t.Errorf("stored %d objects, want original and %d variants", len(objects), len(uploaded.Variants))
+	}
+}
+
+func TestUploadChecksQuotaUnderPropertyLock(t *testing.T) {
+	repo := &fakeImageRepo{usage: &models.ImageUsage{Count: 2}}
+	s := newTestImageService(repo)
+	ctx := context.Background()
+
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	image := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("png"))
+	_, err = s.UploadImageFromBase64(ctx, image, 1, tx)
+
+	var restErr httpErrors.RestErr
+	if !errors.As(err, &restErr) || restErr.Status() != http.StatusRequestEntityTooLarge {
+		t.Fatalf("err = %v, want 413", err)
+	}
+	if want := []string{"lock", "usage"}; !reflect.DeepEqual(repo.calls, want) {
+		t.Errorf("calls = %v, want %v", repo.calls, want)
+	}
+}
+
+// Квота по объёму считается по всем сохраняемым файлам, а не по размеру загрузки
+func TestUploadChecksQuotaAgainstStoredBytes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1600, 1200)), &jpeg.Options{Quality: 1}); err != nil {
+		t.Fatal(err)
+	}
+	data := "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	upload := func(maxBytes int64) (*models.Image, *fakeStorage, error) {
+		s := newTestImageService(&fakeImageRepo{usage: &models.ImageUsage{Bytes: 100}})
+		s.limits.MaxBytesPerProperty = maxBytes
+		tx, err := s.transactionManager.BeginTransaction(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		uploaded, err := s.UploadImageFromBase64(context.Background(), data, 1, tx)
+		return uploaded, s.storage.(*fakeStorage), err
+	}
+
+	uploaded, _, err := upload(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploaded.Bytes <= int64(buf.Len()) {
+		t.Fatalf("stored %d bytes for a %d byte upload, the test needs more", uploaded.Bytes, buf.Len())
+	}
+
+	if _, _, err := upload(100 + uploaded.Bytes); err != nil {
+		t.Errorf("upload that fits exactly: %v", err)
+	}
+
+	_, storage, err := upload(100 + uploaded.Bytes - 1)
+	var restErr httpErrors.RestErr
+	if !errors.As(err, &restErr) || restErr.Status() != http.StatusRequestEntityTooLarge {
+		t.Fatalf("err = %v, want 413", err)
+	}
+	if len(storage.objects) != 0 {
+		t.Errorf("stored %d objects after the quota check failed", len(storage.objects))
+	}
+}
+
+// Подпись без записи в журнале не сохраняется
+func TestUpdateCaptionIsAuditedInTransaction(t *testing.T) {
+	for _, auditErr := range []error{nil, errors.New("audit log is unavailable")} {
+		s := newTestImageService(&fakeImageRepo{})
+		s.audit = audit.NewAuditService(fakeAuditRepo{err: auditErr}, s.log)
+		tm := s.transactionManager.(*dbtest.TransactionManager)
+
+		image, err := s.UpdateCaption(context.Background(), 1, "new")
+		if auditErr == nil {
+			if err != nil || image.Caption != "new" || tm.Commits() != 1 {
+				t.Errorf("UpdateCaption = %+v, %v with %d commits, want caption new and 1 commit", image, err, tm.Commits())
+			}
+			continue
+		}
+		if err == nil || tm.Commits() != 0 || tm.Rollbacks() != 1 {
+			t.Errorf("with failing audit: err = %v, commits = %d, rollbacks = %d", err, tm.Commits(), tm.Rollbacks())
+		}
+	}
+}
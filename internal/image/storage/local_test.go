@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalStorage(t *testing.T, publicURL string) (*localStorage, string) {
+	t.Helper()
+	root := t.TempDir()
+	s, err := NewLocalStorage(root, publicURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*localStorage), root
+}
+
+func TestLocalStorage(t *testing.T) {
+	s, _ := newTestLocalStorage(t, "")
+	testImageStorage(t, s, "")
+}
+
+func TestLocalStoragePath(t *testing.T) {
+	s, root := newTestLocalStorage(t, "")
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "properties/1/a.jpg", want: filepath.Join(root, "properties", "1", "a.jpg")},
+		{key: "a.jpg", want: filepath.Join(root, "a.jpg")},
+		{key: "", wantErr: true},
+		{key: "/", wantErr: true},
+		{key: ".", wantErr: true},
+		{key: "..", wantErr: true},
+		{key: "../secret", wantErr: true},
+		{key: "../../etc/passwd", wantErr: true},
+		{key: "properties/../../secret", wantErr: true},
+		{key: "properties/1/../2/a.jpg", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+		{key: "properties//1/a.jpg", wantErr: true},
+		{key: "properties/1/", wantErr: true},
+		{key: "./a.jpg", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := s.path(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("path = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Ключи с выходом за root отклоняются всеми методами, и файлы вне root не затрагиваются
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "images")
+	s, err := NewLocalStorage(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(dir, "secret")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	const key = "../secret"
+	if err := s.Put(ctx, key, strings.NewReader("overwritten"), 11, "image/jpeg"); err == nil {
+		t.Error("Put succeeded")
+	}
+	if r, err := s.Get(ctx, key); err == nil {
+		r.Close()
+		t.Error("Get succeeded")
+	}
+	if err := s.Delete(ctx, key); err == nil {
+		t.Error("Delete succeeded")
+	}
+
+	data, err := os.ReadFile(outside)
+	if err != nil || string(data) != "secret" {
+		t.Errorf("file outside root = %q, %v", data, err)
+	}
+}
+
+// failingReader отдаёт часть данных и обрывается, как прерванная загрузка
+type failingReader struct {
+	sent bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("connection reset")
+	}
+	r.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalStoragePutIsAtomic(t *testing.T) {
+	s, root := newTestLocalStorage(t, "")
+	ctx := context.Background()
+	const key = "properties/1/a.jpg"
+
+	if err := s.Put(ctx, key, strings.NewReader("complete"), 8, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, key, &failingReader{}, 100, "image/jpeg"); err == nil {
+		t.Fatal("Put with failing reader succeeded")
+	}
+
+	// Прерванная загрузка не портит прежний объект и не оставляет временных файлов
+	r, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "complete" {
+		t.Errorf("Get = %q, %v, want %q", data, err, "complete")
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "properties", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the object", len(entries))
+	}
+}
+
+func TestLocalStorageListSkipsTempFiles(t *testing.T) {
+	s, root := newTestLocalStorage(t, "")
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "properties/1/a.jpg", strings.NewReader("a"), 1, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "properties", "1", tempPrefix+"123"), []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	err := s.List(ctx, "", func(object ObjectInfo) error {
+		keys = append(keys, object.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "properties/1/a.jpg" {
+		t.Errorf("List = %v, want only properties/1/a.jpg", keys)
+	}
+}
+
+func TestLocalStorageURL(t *testing.T) {
+	tests := []struct {
+		publicURL string
+		want      string
+	}{
+		{publicURL: "", want: ""},
+		{publicURL: "https://cdn.example.com/images", want: "https://cdn.example.com/images/properties/1/a.jpg"},
+		{publicURL: "https://cdn.example.com/images/", want: "https://cdn.example.com/images/properties/1/a.jpg"},
+	}
+	for _, tt := range tests {
+		s, _ := newTestLocalStorage(t, tt.publicURL)
+		got, err := s.URL(context.Background(), "properties/1/a.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("URL with public url %q = %q, want %q", tt.publicURL, got, tt.want)
+		}
+	}
+}
+
+func TestNewLocalStorageRequiresRoot(t *testing.T) {
+	if _, err := NewLocalStorage("", ""); err == nil {
+		t.Error("NewLocalStorage succeeded without root")
+	}
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"net/http"
+	"property-managment-service/internal/config"
+	"strings"
+	"time"
+)
+
+const defaultPresignTTL = 15 * time.Minute
+
+type s3Storage struct {
+	client     *minio.Client
+	bucket     string
+	publicURL  string
+	presignTTL time.Duration
+}
+
+// NewS3Storage подключается к S3-совместимому хранилищу (AWS S3, MinIO) и создаёт бакет, если его нет
+func NewS3Storage(ctx context.Context, cfg config.S3StorageConfig) (ImageStorage, error) {
+	const op = "storage.NewS3Storage"
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	presignTTL := cfg.PresignTTL
+	if presignTTL == 0 {
+		presignTTL = defaultPresignTTL
+	}
+
+	return &s3Storage{
+		client:     client,
+		bucket:     cfg.Bucket,
+		publicURL:  strings.TrimRight(cfg.PublicURL, "/"),
+		presignTTL: presignTTL,
+	}, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	const op = "s3Storage.Put"
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	const op = "s3Storage.Get"
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	// GetObject ленивый: ошибку отсутствия объекта можно узнать только запросом метаданных
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	return object, nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	const op = "s3Storage.Delete"
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	return nil
+}
+
+func (s *s3Storage) URL(ctx context.Context, key string) (string, error) {
+	const op = "s3Storage.URL"
+	if s.publicURL != "" {
+		return s.publicURL + "/" + key, nil
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, s.presignTTL, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return u.String(), nil
+}
+
+func (s *s3Storage) List(ctx context.Context, prefix string, fn func(object ObjectInfo) error) error {
+	const op = "s3Storage.List"
+	ctx, cancel := context.WithCancel(ctx)
+	// Отмена контекста останавливает горутину листинга, если fn вернула ошибку
+	defer cancel()
+
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return fmt.Errorf("%s: %w", op, object.Err)
+		}
+		if err := fn(ObjectInfo{Key: object.Key, Size: object.Size, ModTime: object.LastModified}); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+func (s *s3Storage) mapError(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"property-managment-service/internal/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Тесты S3 запускаются против MinIO или другого S3-совместимого хранилища:
+//
+//	docker run -d -p 9000:9000 minio/minio server /data
+//	S3_TEST_ENDPOINT=localhost:9000 go test -tags integration ./internal/image/storage/
+//
+// Без S3_TEST_ENDPOINT тесты пропускаются.
+func s3TestConfig(t *testing.T) config.S3StorageConfig {
+	t.Helper()
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	cfg := config.S3StorageConfig{
+		Endpoint:  endpoint,
+		Region:    os.Getenv("S3_TEST_REGION"),
+		Bucket:    os.Getenv("S3_TEST_BUCKET"),
+		AccessKey: os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_TEST_SECRET_KEY"),
+		UseSSL:    os.Getenv("S3_TEST_USE_SSL") == "true",
+	}
+	if cfg.Bucket == "" {
+		cfg.Bucket = "property-images-test"
+	}
+	// Значения по умолчанию для MinIO
+	if cfg.AccessKey == "" {
+		cfg.AccessKey = "minioadmin"
+	}
+	if cfg.SecretKey == "" {
+		cfg.SecretKey = "minioadmin"
+	}
+	return cfg
+}
+
+// newTestS3Storage возвращает хранилище и уникальный префикс, объекты под которым удаляются после теста
+func newTestS3Storage(t *testing.T, cfg config.S3StorageConfig) (*s3Storage, string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := NewS3Storage(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := s.(*s3Storage)
+
+	prefix := fmt.Sprintf("test-%d/", time.Now().UnixNano())
+	t.Cleanup(func() {
+		ctx := context.Background()
+		var keys []string
+		err := storage.List(ctx, prefix, func(object ObjectInfo) error {
+			keys = append(keys, object.Key)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+		for _, key := range keys {
+			if err := storage.Delete(ctx, key); err != nil {
+				t.Errorf("cleanup: %v", err)
+			}
+		}
+	})
+	return storage, prefix
+}
+
+func TestS3Storage(t *testing.T) {
+	s, prefix := newTestS3Storage(t, s3TestConfig(t))
+	testImageStorage(t, s, prefix)
+}
+
+// Бакет создаётся при подключении, если его ещё нет
+func TestNewS3StorageCreatesBucket(t *testing.T) {
+	cfg := s3TestConfig(t)
+	cfg.Bucket = fmt.Sprintf("property-images-test-%d", time.Now().UnixNano())
+	s, _ := newTestS3Storage(t, cfg)
+	t.Cleanup(func() {
+		if err := s.client.RemoveBucket(context.Background(), cfg.Bucket); err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+	})
+
+	exists, err := s.client.BucketExists(context.Background(), cfg.Bucket)
+	if err != nil || !exists {
+		t.Errorf("bucket %s exists = %v, %v", cfg.Bucket, exists, err)
+	}
+}
+
+func TestS3StorageURL(t *testing.T) {
+	cfg := s3TestConfig(t)
+	ctx := context.Background()
+
+	t.Run("presigned", func(t *testing.T) {
+		cfg := cfg
+		cfg.PresignTTL = 5 * time.Minute
+		s, prefix := newTestS3Storage(t, cfg)
+		key := prefix + "properties/1/a.jpg"
+
+		got, err := s.URL(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(u.Path, "/"+cfg.Bucket+"/"+key) {
+			t.Errorf("path = %s, want object %s in bucket %s", u.Path, key, cfg.Bucket)
+		}
+		query := u.Query()
+		if query.Get("X-Amz-Signature") == "" || query.Get("X-Amz-Expires") != "300" {
+			t.Errorf("URL is not presigned for 5 minutes: %s", got)
+		}
+	})
+
+	t.Run("public", func(t *testing.T) {
+		cfg := cfg
+		cfg.PublicURL = "https://cdn.example.com/images/"
+		s, _ := newTestS3Storage(t, cfg)
+
+		got, err := s.URL(ctx, "properties/1/a.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "https://cdn.example.com/images/properties/1/a.jpg"; got != want {
+			t.Errorf("URL = %q, want %q", got, want)
+		}
+	})
+}
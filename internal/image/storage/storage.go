@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"property-managment-service/internal/config"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// ImageStorage хранит файлы изображений по ключу вида properties/<propertyId>/<имя файла>.
+// В БД сохраняется только ключ, поэтому хранилище можно менять без миграции данных.
+type ImageStorage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL возвращает адрес, по которому клиент может скачать объект напрямую,
+	// или пустую строку, если объект доступен только через API
+	URL(ctx context.Context, key string) (string, error)
+	// List обходит объекты с ключами, начинающимися с prefix
+	List(ctx context.Context, prefix string, fn func(object ObjectInfo) error) error
+}
+
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// New создаёт хранилище по настройке backend
+func New(ctx context.Context, cfg config.StorageConfig) (ImageStorage, error) {
+	switch cfg.Backend {
+	case "local", "":
+		return NewLocalStorage(cfg.Local.Root, cfg.Local.PublicURL)
+	case "s3":
+		return NewS3Storage(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testImageStorage проверяет поведение, общее для всех реализаций ImageStorage.
+// Все ключи создаются под prefix, чтобы тесты не мешали друг другу в общем бакете.
+func testImageStorage(t *testing.T, s ImageStorage, prefix string) {
+	ctx := context.Background()
+	put := func(t *testing.T, key, data string) {
+		t.Helper()
+		if err := s.Put(ctx, key, strings.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			t.Fatalf("Put(%s): %v", key, err)
+		}
+	}
+	get := func(t *testing.T, key string) []byte {
+		t.Helper()
+		r, err := s.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", key, err)
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	t.Run("put and get", func(t *testing.T) {
+		key := prefix + "properties/1/a.jpg"
+		put(t, key, "first")
+		if got := get(t, key); !bytes.Equal(got, []byte("first")) {
+			t.Errorf("Get = %q, want %q", got, "first")
+		}
+
+		// Повторный Put заменяет объект целиком
+		put(t, key, "2nd")
+		if got := get(t, key); !bytes.Equal(got, []byte("2nd")) {
+			t.Errorf("Get after overwrite = %q, want %q", got, "2nd")
+		}
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		if _, err := s.Get(ctx, prefix+"properties/1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		key := prefix + "properties/2/b.jpg"
+		put(t, key, "data")
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+		}
+		// Удаление отсутствующего объекта не ошибка: GC и откат загрузки могут удалить его дважды
+		if err := s.Delete(ctx, key); err != nil {
+			t.Errorf("second Delete: %v", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		listPrefix := prefix + "list/"
+		objects := map[string]string{
+			listPrefix + "properties/3/a.jpg":       "aaa",
+			listPrefix + "properties/3/a_thumb.jpg": "a",
+			listPrefix + "properties/30/b.jpg":      "bb",
+			listPrefix + "properties/4/c.jpg":       "c",
+		}
+		for key, data := range objects {
+			put(t, key, data)
+		}
+
+		tests := []struct {
+			prefix string
+			want   []string
+		}{
+			{prefix: listPrefix + "properties/3/", want: []string{listPrefix + "properties/3/a.jpg", listPrefix + "properties/3/a_thumb.jpg"}},
+			{prefix: listPrefix + "properties/3", want: []string{listPrefix + "properties/3/a.jpg", listPrefix + "properties/3/a_thumb.jpg", listPrefix + "properties/30/b.jpg"}},
+			{prefix: listPrefix + "properties/5/"},
+		}
+		for _, tt := range tests {
+			var got []string
+			err := s.List(ctx, tt.prefix, func(object ObjectInfo) error {
+				if want := int64(len(objects[object.Key])); object.Size != want {
+					t.Errorf("%s size = %d, want %d", object.Key, object.Size, want)
+				}
+				if object.ModTime.IsZero() {
+					t.Errorf("%s has no ModTime", object.Key)
+				}
+				got = append(got, object.Key)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("List(%s): %v", tt.prefix, err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List(%s) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		}
+
+		// Ошибка fn прерывает обход и возвращается вызывающему
+		stop := errors.New("stop")
+		calls := 0
+		err := s.List(ctx, listPrefix, func(ObjectInfo) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("List with failing fn: err = %v after %d calls, want stop after 1", err, calls)
+		}
+	})
+}
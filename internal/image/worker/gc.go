@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"property-managment-service/internal/image/gc"
+	"property-managment-service/lib/sl"
+	"time"
+)
+
+// GCWorker периодически сверяет хранилище изображений с БД
+type GCWorker struct {
+	collector *gc.Collector
+	interval  time.Duration
+	dryRun    bool
+	log       *slog.Logger
+}
+
+func NewGCWorker(collector *gc.Collector, interval time.Duration, dryRun bool, log *slog.Logger) *GCWorker {
+	return &GCWorker{collector: collector, interval: interval, dryRun: dryRun, log: log}
+}
+
+func (w *GCWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.collect(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *GCWorker) collect(ctx context.Context) {
+	report, err := w.collector.Run(ctx, w.dryRun)
+	if err != nil {
+		w.log.Error("GCWorker", sl.Err(err))
+		return
+	}
+	if len(report.OrphanedObjects) > 0 || len(report.MissingFiles) > 0 {
+		w.log.Info("GCWorker",
+			slog.Bool("dry_run", report.DryRun),
+			slog.Int("orphaned_objects", len(report.OrphanedObjects)),
+			slog.Int("missing_files", len(report.MissingFiles)),
+			slog.Int("deleted_rows", len(report.DeletedRows)),
+		)
+	}
+}
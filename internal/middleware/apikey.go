@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+type ApiKeyAuthenticator interface {
+	Authenticate(ctx context.Context, raw string) (*models.ApiKey, error)
+}
+
+type apiKeyCtxKey struct{}
+
+// APIKeyOr пропускает запрос с заголовком X-API-Key, если у ключа есть scope.
+// Без заголовка запрос передаётся fallback (обычно AuthJWTMiddleware); nil - маршрут остаётся публичным.
+func (mw *MiddlewareManager) APIKeyOr(scope string, fallback echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withFallback := next
+		if fallback != nil {
+			withFallback = fallback(next)
+		}
+		return func(c echo.Context) error {
+			raw := c.Request().Header.Get(HeaderAPIKey)
+			if raw == "" {
+				return withFallback(c)
+			}
+
+			key, err := mw.apiKeys.Authenticate(c.Request().Context(), raw)
+			if err != nil {
+				return httpErrors.NewUnauthorizedError(httpErrors.Unauthorized)
+			}
+			if !key.HasScope(scope) {
+				mw.log.Warn("api key scope denied", slog.String("api_key", key.Name), slog.String("scope", scope),
+					slog.String("request_id", utils.GetRequestID(c)))
+				return httpErrors.NewForbiddenError(nil)
+			}
+
+			// Запросы сервисов в логах опознаются по имени ключа, id пользователя у них нет
+			mw.log.Info("api key request", slog.String("api_key", key.Name),
+				slog.String("method", c.Request().Method), slog.String("path", c.Path()),
+				slog.String("request_id", utils.GetRequestID(c)))
+
+			ctx := context.WithValue(c.Request().Context(), apiKeyCtxKey{}, key)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// GetApiKey возвращает ключ, которым аутентифицирован запрос, если запрос пришёл от сервиса
+func GetApiKey(c echo.Context) (*models.ApiKey, bool) {
+	return ApiKeyFromContext(c.Request().Context())
+}
+
+// ApiKeyFromContext - то же для сервисного слоя, которому доступен только контекст запроса
+func ApiKeyFromContext(ctx context.Context) (*models.ApiKey, bool) {
+	key, ok := ctx.Value(apiKeyCtxKey{}).(*models.ApiKey)
+	return key, ok && key != nil
+}
@@ -1,60 +1,125 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 	"net/http"
-	"property-managment-service/internal/config"
+	"property-managment-service/internal/authz"
 	"property-managment-service/pkg/httpErrors"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// validMethods - допустимые алгоритмы подписи; none и прочие отклоняются до поиска ключа
+var validMethods = []string{"HS256", "HS384", "HS512", "RS256", "ES256"}
+
 func (mw *MiddlewareManager) AuthJWTMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Извлекаем токен из куки
-			cookieToken, err := c.Cookie("token")
+			token, err := extractToken(c)
 			if err != nil {
-				if errors.Is(err, http.ErrNoCookie) {
-					return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.ErrNoCookie))
-				}
-				return c.JSON(http.StatusInternalServerError, httpErrors.NewUnauthorizedError(httpErrors.Unauthorized))
-			}
-
-			token := cookieToken.Value
-			if token == "" {
-				return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.Unauthorized))
+				return httpErrors.NewUnauthorizedError(err)
 			}
 
 			// Валидация и разбор токена
-			claims, err := mw.validateJWTToken(token, mw.cfg)
+			claims, err := mw.validateJWTToken(c.Request().Context(), token)
 			if err != nil {
 				mw.log.Error("middleware validateJWTToken", "header JWT", err.Error())
-				return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.Unauthorized))
+				return httpErrors.NewUnauthorizedError(httpErrors.Unauthorized)
 			}
 
-			// Добавляем данные из токена в контекст
-			c.Set("user", claims)
+			// Добавляем данные из токена в контекст запроса
+			c.SetRequest(c.Request().WithContext(authz.WithClaims(c.Request().Context(), claims)))
 
 			return next(c)
 		}
 	}
 }
 
-func (mw *MiddlewareManager) validateJWTToken(tokenString string, cfg *config.Config) (map[string]interface{}, error) {
+// OptionalAuthJWTMiddleware проверяет токен, если он передан, и пропускает анонимные запросы.
+// Нужен публичным маршрутам, которые показывают владельцу больше, чем остальным.
+func (mw *MiddlewareManager) OptionalAuthJWTMiddleware() echo.MiddlewareFunc {
+	auth := mw.AuthJWTMiddleware()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withAuth := auth(next)
+		return func(c echo.Context) error {
+			if _, err := extractToken(c); errors.Is(err, httpErrors.ErrNoCookie) {
+				return next(c)
+			}
+			return withAuth(c)
+		}
+	}
+}
+
+// RequireRole пропускает пользователей хотя бы с одной из ролей; администратору доступно всё.
+// Ставится после AuthJWTMiddleware.
+func (mw *MiddlewareManager) RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, err := GetClaims(c)
+			if err != nil {
+				return httpErrors.NewUnauthorizedError(err)
+			}
+			if claims.IsAdmin() {
+				return next(c)
+			}
+			for _, role := range roles {
+				if claims.HasRole(role) {
+					return next(c)
+				}
+			}
+			return httpErrors.NewForbiddenError(nil)
+		}
+	}
+}
+
+// GetClaims возвращает данные токена, сохранённые AuthJWTMiddleware
+func GetClaims(c echo.Context) (*authz.Claims, error) {
+	claims, ok := authz.ClaimsFromContext(c.Request().Context())
+	if !ok {
+		return nil, httpErrors.Unauthorized
+	}
+	return claims, nil
+}
+
+// extractToken берёт токен из заголовка Authorization: Bearer (мобильное приложение, другие сервисы),
+// а если заголовка нет - из куки token (браузер)
+func extractToken(c echo.Context) (string, error) {
+	if header := c.Request().Header.Get(echo.HeaderAuthorization); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return "", httpErrors.InvalidJWTToken
+		}
+		return strings.TrimSpace(token), nil
+	}
+
+	cookie, err := c.Cookie("token")
+	if err != nil {
+		if errors.Is(err, http.ErrNoCookie) {
+			return "", httpErrors.ErrNoCookie
+		}
+		return "", httpErrors.Unauthorized
+	}
+	if cookie.Value == "" {
+		return "", httpErrors.Unauthorized
+	}
+	return cookie.Value, nil
+}
+
+func (mw *MiddlewareManager) validateJWTToken(ctx context.Context, tokenString string) (*authz.Claims, error) {
 	if tokenString == "" {
 		return nil, httpErrors.InvalidJWTToken
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signin method %v", token.Header["alg"])
-		}
-		secret := []byte(cfg.Server.JwtSecretKey)
-		return secret, nil
+	// Временные claims проверяем сами, чтобы учесть Leeway
+	parser := &jwt.Parser{ValidMethods: validMethods, SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return mw.verificationKey(ctx, token)
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -63,16 +128,129 @@ func (mw *MiddlewareManager) validateJWTToken(tokenString string, cfg *config.Co
 		return nil, httpErrors.InvalidJWTToken
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
-
 	if !ok {
-		return nil, fmt.Errorf("invalid claims") // todo: Нормальная ошибка
+		return nil, httpErrors.InvalidJWTClaims
+	}
+	if err := mw.verifyClaims(claims, time.Now()); err != nil {
+		return nil, err
 	}
 
-	data := make(map[string]interface{})
-	for key, value := range claims {
-		data[key] = value
+	return parseClaims(claims)
+}
+
+// verificationKey выбирает ключ по алгоритму токена: общий секрет для HMAC, ключ из JWKS по kid для RS256/ES256
+func (mw *MiddlewareManager) verificationKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if mw.cfg.Server.JwtSecretKey == "" {
+			return nil, fmt.Errorf("hmac tokens are disabled")
+		}
+		return []byte(mw.cfg.Server.JwtSecretKey), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if mw.keys == nil {
+			return nil, fmt.Errorf("jwks is not configured")
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, err := mw.keys.Key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if err := key.Verifies(token.Method.Alg()); err != nil {
+			return nil, err
+		}
+		return key.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signin method %v", token.Header["alg"])
 	}
+}
 
-	return data, nil
+// verifyClaims проверяет exp, nbf, iss и aud по настройкам Auth
+func (mw *MiddlewareManager) verifyClaims(claims jwt.MapClaims, now time.Time) error {
+	cfg := mw.cfg.Auth
 
+	if exp, ok := claims["exp"]; ok {
+		seconds, err := int64Claim(exp)
+		if err != nil {
+			return fmt.Errorf("%w: exp", httpErrors.InvalidJWTClaims)
+		}
+		if now.After(time.Unix(seconds, 0).Add(cfg.Leeway)) {
+			return fmt.Errorf("%w: token is expired", httpErrors.InvalidJWTToken)
+		}
+	} else if cfg.RequireExp {
+		return fmt.Errorf("%w: exp is required", httpErrors.InvalidJWTClaims)
+	}
+
+	if nbf, ok := claims["nbf"]; ok {
+		seconds, err := int64Claim(nbf)
+		if err != nil {
+			return fmt.Errorf("%w: nbf", httpErrors.InvalidJWTClaims)
+		}
+		if now.Add(cfg.Leeway).Before(time.Unix(seconds, 0)) {
+			return fmt.Errorf("%w: token is not valid yet", httpErrors.InvalidJWTToken)
+		}
+	}
+
+	if cfg.Issuer != "" && !claims.VerifyIssuer(cfg.Issuer, true) {
+		return fmt.Errorf("%w: iss", httpErrors.InvalidJWTClaims)
+	}
+	if cfg.Audience != "" && !claims.VerifyAudience(cfg.Audience, true) {
+		return fmt.Errorf("%w: aud", httpErrors.InvalidJWTClaims)
+	}
+	return nil
+}
+
+// parseClaims переводит claims токена в типизированный вид; неверный тип любого поля - ошибка
+func parseClaims(claims jwt.MapClaims) (*authz.Claims, error) {
+	userId, err := int64Claim(claims["uid"])
+	if err != nil || userId <= 0 {
+		return nil, fmt.Errorf("%w: uid", httpErrors.InvalidJWTClaims)
+	}
+
+	var roles []string
+	if role, ok := claims["role"]; ok {
+		r, ok := role.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: role", httpErrors.InvalidJWTClaims)
+		}
+		roles = append(roles, r)
+	}
+	if list, ok := claims["roles"]; ok {
+		items, ok := list.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: roles", httpErrors.InvalidJWTClaims)
+		}
+		for _, item := range items {
+			r, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: roles", httpErrors.InvalidJWTClaims)
+			}
+			roles = append(roles, r)
+		}
+	}
+
+	var expiresAt time.Time
+	if exp, ok := claims["exp"]; ok {
+		seconds, err := int64Claim(exp)
+		if err != nil {
+			return nil, fmt.Errorf("%w: exp", httpErrors.InvalidJWTClaims)
+		}
+		expiresAt = time.Unix(seconds, 0)
+	}
+
+	return authz.NewClaims(userId, roles, expiresAt), nil
+}
+
+// int64Claim принимает число из JSON (float64) или строку с целым числом
+func int64Claim(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("not an integer: %v", v)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected type %T", value)
+	}
 }
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"strconv"
+)
+
+// IdExtractor достаёт из запроса id ресурса, владельца которого нужно проверить
+type IdExtractor func(c echo.Context) (int64, error)
+
+func PathParam(name string) IdExtractor {
+	return func(c echo.Context) (int64, error) {
+		return strconv.ParseInt(c.Param(name), 10, 64)
+	}
+}
+
+func FormValue(name string) IdExtractor {
+	return func(c echo.Context) (int64, error) {
+		return strconv.ParseInt(c.FormValue(name), 10, 64)
+	}
+}
+
+// RequireOwner пропускает запрос, только если пользователь владеет ресурсом или является администратором.
+// Ставится после AuthJWTMiddleware или APIKeyOr; у сервиса с ключом доступ уже проверен по scope.
+func (mw *MiddlewareManager) RequireOwner(resource authz.Resource, extract IdExtractor) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if _, ok := GetApiKey(c); ok {
+				return next(c)
+			}
+
+			claims, err := GetClaims(c)
+			if err != nil {
+				return httpErrors.NewUnauthorizedError(err)
+			}
+
+			id, err := extract(c)
+			if err != nil {
+				return httpErrors.NewBadRequestError("invalid id")
+			}
+
+			if err := mw.authorizer.CheckOwner(utils.GetRequestCtx(c), claims, resource, id); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
@@ -2,14 +2,26 @@ package middleware
 
 import (
 	"log/slog"
+	"property-managment-service/internal/authz"
 	"property-managment-service/internal/config"
+	"property-managment-service/pkg/jwks"
 )
 
 type MiddlewareManager struct {
-	log *slog.Logger
-	cfg *config.Config
+	log        *slog.Logger
+	cfg        *config.Config
+	authorizer *authz.Authorizer
+	// keys - ключи для RS256/ES256; nil, если JWKS не настроен
+	keys    *jwks.KeySet
+	apiKeys ApiKeyAuthenticator
 }
 
-func NewMiddlewareManager(log *slog.Logger, cfg *config.Config) *MiddlewareManager {
-	return &MiddlewareManager{log: log, cfg: cfg}
+func NewMiddlewareManager(
+	log *slog.Logger,
+	cfg *config.Config,
+	authorizer *authz.Authorizer,
+	keys *jwks.KeySet,
+	apiKeys ApiKeyAuthenticator,
+) *MiddlewareManager {
+	return &MiddlewareManager{log: log, cfg: cfg, authorizer: authorizer, keys: keys, apiKeys: apiKeys}
 }
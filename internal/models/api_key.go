@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ApiKey - ключ доступа внутреннего сервиса. Сам ключ не хранится, только его хэш
+type ApiKey struct {
+	Id         int64        `json:"id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"-" db:"key_hash"`
+	Scopes     ApiKeyScopes `json:"scopes"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+	LastUsedAt *time.Time   `json:"lastUsedAt" db:"last_used_at"`
+	RevokedAt  *time.Time   `json:"revokedAt" db:"revoked_at"`
+}
+
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiKeyScopes хранится в JSONB как массив строк
+type ApiKeyScopes []string
+
+func (s ApiKeyScopes) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(s))
+}
+
+func (s *ApiKeyScopes) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		*s = ApiKeyScopes{}
+		return nil
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	default:
+		return fmt.Errorf("cannot scan %T into ApiKeyScopes", src)
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionArchive = "archive"
+	AuditActionRestore = "restore"
+
+	AuditEntityProperty        = "property"
+	AuditEntityPropertyDetails = "property_details"
+	AuditEntityImage           = "image"
+	AuditEntityBooking         = "booking"
+)
+
+// AuditEntry - запись журнала изменений. ActorId и ActorKey пусты, если изменение сделала фоновая задача
+type AuditEntry struct {
+	Id        int64     `json:"id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	ActorId   *int64    `json:"actorId" db:"actor_id"`
+	ActorKey  *string   `json:"actorKey,omitempty" db:"actor_key"`
+	RequestId string    `json:"requestId" db:"request_id"`
+	Action    string    `json:"action"`
+	Entity    string    `json:"entity"`
+	EntityId  int64     `json:"entityId" db:"entity_id"`
+	Diff      AuditDiff `json:"diff"`
+}
+
+// AuditDiff хранится в JSONB: при создании заполнен только After, при удалении - только Before
+type AuditDiff struct {
+	Before map[string]json.RawMessage `json:"before,omitempty"`
+	After  map[string]json.RawMessage `json:"after,omitempty"`
+}
+
+func (d AuditDiff) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *AuditDiff) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		*d = AuditDiff{}
+		return nil
+	case []byte:
+		return json.Unmarshal(data, d)
+	case string:
+		return json.Unmarshal([]byte(data), d)
+	default:
+		return fmt.Errorf("cannot scan %T into AuditDiff", src)
+	}
+}
+
+// AuditFilter - условия выборки журнала; нулевые значения означают "не задано"
+type AuditFilter struct {
+	Entity   string
+	EntityId int64
+	ActorId  int64
+	From     *time.Time
+	To       *time.Time
+	// BeforeId - курсор: id последней записи предыдущей страницы
+	BeforeId int64
+	Limit    int
+}
+
+// AuditPage - страница журнала; NextBeforeId передаётся в beforeId следующего запроса
+type AuditPage struct {
+	Items        []*AuditEntry `json:"items"`
+	NextBeforeId int64         `json:"nextBeforeId,omitempty"`
+}
@@ -2,11 +2,23 @@ package models
 
 type Booking struct {
 	Id           int64  `json:"id"`
-	PropertyId   int64  `json:"propertyId"`
-	UserId       int64  `json:"userId"`
-	CheckInDate  string `json:"checkInDate" validate:"datetime=2006-01-02"`
-	CheckOutDate string `json:"checkOutDate" validate:"datetime=2006-01-02"`
-	TotalPrice   int    `json:"totalPrice"`
-	Status       string `json:"status" validate:"oneof= confirmed pending cancelled"`
-	CreatedAt    string `json:"createdAt" validate:"datetime=2006-01-02"`
+	PropertyId   int64  `json:"propertyId" db:"property_id"`
+	UserId       int64  `json:"userId" db:"user_id"`
+	CheckInDate  string `json:"checkInDate" db:"check_in_date" validate:"datetime=2006-01-02"`
+	CheckOutDate string `json:"checkOutDate" db:"check_out_date" validate:"datetime=2006-01-02"`
+	TotalPrice   int    `json:"totalPrice" db:"total_price"`
+	Status       string `json:"status" validate:"oneof=confirmed pending cancelled declined"`
+	CreatedAt    string `json:"createdAt" db:"created_at" validate:"datetime=2006-01-02"`
+	// RequestedAt - момент создания заявки, от него отсчитывается срок ожидания ответа владельца
+	RequestedAt string `json:"requestedAt" db:"requested_at"`
+}
+
+type BookingStatusChange struct {
+	Id         int64  `json:"id"`
+	BookingId  int64  `json:"bookingId" db:"booking_id"`
+	FromStatus string `json:"fromStatus" db:"from_status"`
+	ToStatus   string `json:"toStatus" db:"to_status"`
+	ChangedBy  *int64 `json:"changedBy" db:"changed_by"`
+	Reason     string `json:"reason" db:"reason"`
+	ChangedAt  string `json:"changedAt" db:"changed_at"`
 }
@@ -1,7 +1,66 @@
 package models
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Image.ImageUrl хранит ключ исходного изображения в хранилище (перекодированного, без EXIF), Url - прямую ссылку,
+// если хранилище её выдаёт. Bytes - объём исходного файла вместе со всеми вариантами, по нему считается квота
 type Image struct {
-	Id         int64  `json:"id"`
-	PropertyId int64  `json:"propertyId" db:"property_id"`
-	ImageUrl   string `json:"imageUrl" db:"image_url"`
+	Id         int64         `json:"id"`
+	PropertyId int64         `json:"propertyId" db:"property_id"`
+	ImageUrl   string        `json:"imageUrl" db:"image_url"`
+	Url        string        `json:"url,omitempty" db:"-"`
+	Width      int           `json:"width"`
+	Height     int           `json:"height"`
+	Bytes      int64         `json:"bytes"`
+	Variants   ImageVariants `json:"variants" db:"variants"`
+	Position   int           `json:"position"`
+	IsCover    bool          `json:"isCover" db:"is_cover"`
+	Caption    string        `json:"caption"`
+}
+
+// ImageUsage - сколько изображений уже загружено для объекта и сколько байт занимают их файлы вместе с вариантами
+type ImageUsage struct {
+	Count int   `db:"count"`
+	Bytes int64 `db:"bytes"`
+}
+
+type ImageVariant struct {
+	Key    string `json:"key"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Bytes  int64  `json:"bytes"`
+	Url    string `json:"url,omitempty"`
+}
+
+// ImageVariants - уменьшенные копии изображения по названию размера (thumb, medium, large), хранятся в JSONB
+type ImageVariants map[string]ImageVariant
+
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	stored := make(ImageVariants, len(v))
+	for name, variant := range v {
+		variant.Url = ""
+		stored[name] = variant
+	}
+	return json.Marshal(stored)
+}
+
+func (v *ImageVariants) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		*v = ImageVariants{}
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("cannot scan %T into ImageVariants", src)
+	}
 }
@@ -1,13 +1,41 @@
 package models
 
+import "time"
+
+// Статусы публикации объявления; публичные выборки показывают только PropertyStatusPublished
+const (
+	PropertyStatusDraft         = "draft"
+	PropertyStatusPendingReview = "pending_review"
+	PropertyStatusPublished     = "published"
+	PropertyStatusSuspended     = "suspended"
+)
+
 type Property struct {
-	ID           int64  `json:"id"`
-	OwnerId      int64  `json:"ownerId" db:"owner_id"`
-	Title        string `json:"title"`
-	Location     string `json:"location"`
-	Price        int    `json:"price"`
-	PropertyType string `json:"propertyType" db:"property_type" validate:"oneof=house apartment"`
-	RentalType   string `json:"rentalType" db:"rental_type" validate:"oneof=shortTerm longTerm"`
-	MaxGuests    int    `json:"maxGuests" db:"max_guests"`
-	CreatedAt    string `json:"createdAt" db:"created_at"`
+	ID           int64    `json:"id"`
+	OwnerId      int64    `json:"ownerId" db:"owner_id"`
+	Title        string   `json:"title"`
+	Location     string   `json:"location"`
+	Price        int      `json:"price"`
+	PropertyType string   `json:"propertyType" db:"property_type" validate:"oneof=house apartment"`
+	RentalType   string   `json:"rentalType" db:"rental_type" validate:"oneof=shortTerm longTerm"`
+	MaxGuests    int      `json:"maxGuests" db:"max_guests"`
+	CreatedAt    string   `json:"createdAt" db:"created_at"`
+	Rating       float64  `json:"rating" db:"rating"`
+	ReviewCount  int      `json:"reviewCount" db:"review_count"`
+	Latitude     *float64 `json:"latitude" db:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude    *float64 `json:"longitude" db:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	// Version меняется при каждом изменении объекта, кроме пересчёта Rating и ReviewCount по отзывам;
+	// отдаётся в ETag, ожидаемая версия приходит в If-Match
+	Version int64 `json:"version" db:"version"`
+	// Status меняется только через отправку на проверку и модерацию; StatusReason - причина приостановки
+	Status       string  `json:"status" db:"status"`
+	StatusReason *string `json:"statusReason,omitempty" db:"status_reason"`
+	// DeletedAt - время архивации; архивные объявления не попадают в списки и поиск
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	// Distance - расстояние в метрах до точки поиска near, заполняется только при поиске по радиусу
+	Distance *float64 `json:"distance,omitempty" db:"distance"`
+	// Обложка заполняется только в списках объявлений: CoverImageKey - ключ в хранилище, CoverImageUrl - ссылка для клиента
+	CoverImageId  *int64  `json:"coverImageId,omitempty" db:"cover_image_id"`
+	CoverImageKey *string `json:"-" db:"cover_image_key"`
+	CoverImageUrl string  `json:"coverImageUrl,omitempty" db:"-"`
 }
@@ -2,7 +2,21 @@ package models
 
 type PropertyAvailability struct {
 	Id          int64  `json:"id"`
-	PropertyId  int64  `json:"propertyId"`
+	PropertyId  int64  `json:"propertyId" db:"property_id"`
 	Date        string `json:"date" validate:"datetime=2006-01-02"`
-	IsAvailable bool   `json:"isAvailable"`
+	IsAvailable bool   `json:"isAvailable" db:"is_available"`
+	Source      string `json:"source"`
+}
+
+// CalendarDay - доступность объекта на конкретный день
+type CalendarDay struct {
+	Date      string `json:"date"`
+	Available bool   `json:"available"`
+	Status    string `json:"status"`
+}
+
+// DateRange - полуинтервал дат [CheckIn, CheckOut)
+type DateRange struct {
+	CheckIn  string `json:"checkIn" db:"check_in_date"`
+	CheckOut string `json:"checkOut" db:"check_out_date"`
 }
@@ -9,4 +9,5 @@ type PropertyDetails struct {
 	HouseCreationYear int    `json:"houseCreationYear" db:"house_creation_year"`
 	HouseType         string `json:"houseType" db:"house_type"`
 	Description       string `json:"description" db:"description"`
+	Version           int64  `json:"version" db:"version"`
 }
@@ -0,0 +1,49 @@
+package models
+
+// PropertyFilter - фильтры выборки объявлений. Нулевые значения не ограничивают выборку.
+type PropertyFilter struct {
+	PropertyType string
+	RentalType   string
+	MinPrice     int
+	MaxPrice     int
+	MinGuests    int
+	MinRooms     int
+	MaxRooms     int
+	MinArea      int
+	MaxArea      int
+	// Near и RadiusMeters ограничивают выборку кругом на карте, BBox - прямоугольником
+	Near         *Coordinates
+	RadiusMeters float64
+	BBox         *BoundingBox
+}
+
+// PropertyListQuery - страница выборки для keyset-пагинации: записи строго после (AfterValue, AfterId)
+// в порядке сортировки SortField. Sort - исходное значение параметра sort.
+type PropertyListQuery struct {
+	Filter     PropertyFilter
+	Sort       string
+	SortField  string
+	Desc       bool
+	AfterValue *string
+	AfterId    int64
+	Limit      int
+}
+
+type PropertyPage struct {
+	Items      []*Property `json:"items"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// PropertySearchResult - объявление, найденное полнотекстовым поиском, с релевантностью и
+// фрагментами текста, в которых совпадения выделены тегом <b>. Сам текст во фрагментах экранирован как HTML.
+type PropertySearchResult struct {
+	Property
+	Rank                 float64 `json:"rank" db:"rank"`
+	TitleHighlight       string  `json:"titleHighlight" db:"title_highlight"`
+	DescriptionHighlight string  `json:"descriptionHighlight" db:"description_highlight"`
+}
+
+type PropertySearchPage struct {
+	Items      []*PropertySearchResult `json:"items"`
+	NextCursor string                  `json:"nextCursor,omitempty"`
+}
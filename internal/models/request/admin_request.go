@@ -0,0 +1,20 @@
+package request
+
+type BulkDeletePropertiesRequest struct {
+	Ids []int64 `json:"ids" validate:"required,min=1,max=100,unique"`
+}
+
+type SuspendPropertyRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// AuditLogRequest - фильтры журнала изменений; from и to в RFC 3339, to не включается
+type AuditLogRequest struct {
+	Entity   string `query:"entity" validate:"omitempty,oneof=property property_details image booking"`
+	EntityId int64  `query:"entityId" validate:"min=0"`
+	ActorId  int64  `query:"actorId" validate:"min=0"`
+	From     string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To       string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	BeforeId int64  `query:"beforeId" validate:"min=0"`
+	Limit    int    `query:"limit" validate:"min=0,max=500"`
+}
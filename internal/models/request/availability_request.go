@@ -0,0 +1,8 @@
+package request
+
+// AvailabilityRequest задаёт даты для закрытия/открытия: диапазон From..To (включительно) и/или отдельные дни
+type AvailabilityRequest struct {
+	From  string   `json:"from" validate:"omitempty,datetime=2006-01-02"`
+	To    string   `json:"to" validate:"omitempty,datetime=2006-01-02"`
+	Dates []string `json:"dates" validate:"dive,datetime=2006-01-02"`
+}
@@ -0,0 +1,5 @@
+package request
+
+type BookingTransitionRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
@@ -0,0 +1,7 @@
+package request
+
+type CreateBookingRequest struct {
+	PropertyId   int64  `json:"propertyId" validate:"required"`
+	CheckInDate  string `json:"checkInDate" validate:"required,datetime=2006-01-02"`
+	CheckOutDate string `json:"checkOutDate" validate:"required,datetime=2006-01-02"`
+}
@@ -0,0 +1,14 @@
+package request
+
+type ReorderImagesRequest struct {
+	// ImageIds - все изображения объекта в новом порядке
+	ImageIds []int64 `json:"imageIds" validate:"required,min=1,unique"`
+}
+
+type SetCoverImageRequest struct {
+	ImageId int64 `json:"imageId" validate:"required"`
+}
+
+type ImageCaptionRequest struct {
+	Caption string `json:"caption" validate:"max=500"`
+}
@@ -0,0 +1,26 @@
+package request
+
+// PatchPropertyRequest - JSON Merge Patch объявления. Поля-указатели: отсутствующее поле не меняется,
+// null очищает его; для колонок NOT NULL null отклоняется проверкой required.
+type PatchPropertyRequest struct {
+	Title        *string  `json:"title" db:"title" validate:"required,min=1,max=255"`
+	Location     *string  `json:"location" db:"location" validate:"required,min=1"`
+	Price        *int     `json:"price" db:"price" validate:"required,min=0"`
+	PropertyType *string  `json:"propertyType" db:"property_type" validate:"required,oneof=house apartment"`
+	RentalType   *string  `json:"rentalType" db:"rental_type" validate:"required,oneof=shortTerm longTerm"`
+	MaxGuests    *int     `json:"maxGuests" db:"max_guests" validate:"required,min=1"`
+	Latitude     *float64 `json:"latitude" db:"latitude" validate:"omitempty,latitude"`
+	Longitude    *float64 `json:"longitude" db:"longitude" validate:"omitempty,longitude"`
+}
+
+// PatchPropertyDetailsRequest - JSON Merge Patch деталей объекта. Поля модели не допускают NULL,
+// поэтому null отклоняется для всех полей.
+type PatchPropertyDetailsRequest struct {
+	Floor             *int    `json:"floor" db:"floor" validate:"required"`
+	MaxFloor          *int    `json:"maxFloor" db:"max_floor" validate:"required,min=0"`
+	Area              *int    `json:"area" db:"area" validate:"required,min=1"`
+	Rooms             *int    `json:"rooms" db:"rooms" validate:"required,min=0"`
+	HouseCreationYear *int    `json:"houseCreationYear" db:"house_creation_year" validate:"required,min=1000,max=9999"`
+	HouseType         *string `json:"houseType" db:"house_type" validate:"required"`
+	Description       *string `json:"description" db:"description" validate:"required,max=5000"`
+}
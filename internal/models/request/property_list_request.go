@@ -0,0 +1,27 @@
+package request
+
+// PropertyListRequest - параметры выборки объявлений. Нулевые значения фильтров означают "не задано".
+// Near задаётся как "lat,lng", Radius - в метрах, BBox - как "minLat,minLng,maxLat,maxLng".
+type PropertyListRequest struct {
+	PropertyType string  `query:"propertyType" validate:"omitempty,oneof=house apartment"`
+	RentalType   string  `query:"rentalType" validate:"omitempty,oneof=shortTerm longTerm"`
+	MinPrice     int     `query:"minPrice" validate:"min=0"`
+	MaxPrice     int     `query:"maxPrice" validate:"min=0"`
+	MinGuests    int     `query:"minGuests" validate:"min=0"`
+	MinRooms     int     `query:"minRooms" validate:"min=0"`
+	MaxRooms     int     `query:"maxRooms" validate:"min=0"`
+	MinArea      int     `query:"minArea" validate:"min=0"`
+	MaxArea      int     `query:"maxArea" validate:"min=0"`
+	Near         string  `query:"near"`
+	Radius       float64 `query:"radius" validate:"min=0,max=100000"`
+	BBox         string  `query:"bbox"`
+	Sort         string  `query:"sort" validate:"omitempty,oneof=price -price createdAt -createdAt rating -rating relevance distance"`
+	Limit        int     `query:"limit" validate:"min=0,max=100"`
+	Cursor       string  `query:"cursor"`
+}
+
+// PropertySearchRequest - полнотекстовый поиск с теми же фильтрами и пагинацией, что и у выборки
+type PropertySearchRequest struct {
+	PropertyListRequest
+	Query string `query:"q" validate:"required,max=200"`
+}
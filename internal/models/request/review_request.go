@@ -0,0 +1,11 @@
+package request
+
+type CreateReviewRequest struct {
+	BookingId int64  `json:"bookingId" validate:"required"`
+	Rating    int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment   string `json:"comment" validate:"max=2000"`
+}
+
+type ReviewReplyRequest struct {
+	Reply string `json:"reply" validate:"required,max=2000"`
+}
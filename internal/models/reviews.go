@@ -1,10 +1,13 @@
 package models
 
 type Review struct {
-	Id         int64  `json:"id"`
-	PropertyId int64  `json:"propertyId"`
-	UserId     int64  `json:"userId"`
-	Rating     int    `json:"rating"`
-	Comment    string `json:"comment"`
-	CreatedAt  string `json:"createdAt" validate:"datetime=2006-01-02"`
+	Id         int64   `json:"id"`
+	PropertyId int64   `json:"propertyId" db:"property_id"`
+	UserId     int64   `json:"userId" db:"user_id"`
+	BookingId  *int64  `json:"bookingId" db:"booking_id"`
+	Rating     int     `json:"rating"`
+	Comment    string  `json:"comment"`
+	CreatedAt  string  `json:"createdAt" db:"created_at" validate:"datetime=2006-01-02"`
+	Reply      *string `json:"reply" db:"reply"`
+	RepliedAt  *string `json:"repliedAt" db:"replied_at"`
 }
@@ -6,7 +6,10 @@ import (
 	"github.com/labstack/echo/v4"
 	"log/slog"
 	"net/http"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/middleware"
 	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
 	"property-managment-service/pkg/httpErrors"
 	"property-managment-service/pkg/utils"
 	"strconv"
@@ -15,7 +18,8 @@ import (
 type PropertyDetailsService interface {
 	Create(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error)
 	GetById(ctx context.Context, id int64) (*models.PropertyDetails, error)
-	Update(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error)
+	Update(ctx context.Context, details *models.PropertyDetails, ifMatch []int64) (*models.PropertyDetails, error)
+	Patch(ctx context.Context, propertyId int64, patch *utils.MergePatch, ifMatch []int64) (*models.PropertyDetails, error)
 	Delete(ctx context.Context, id int64) (int64, error)
 	SaveWithTx(ctx context.Context, details *models.PropertyDetails, tx *sqlx.Tx) error
 	DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error
@@ -23,11 +27,12 @@ type PropertyDetailsService interface {
 
 type propertyDetailsHandlers struct {
 	propertyDetailsService PropertyDetailsService
+	authorizer             *authz.Authorizer
 	log                    *slog.Logger
 }
 
-func NewPropertyDetailsHandlers(service PropertyDetailsService, log *slog.Logger) PropertyDetailsHandlers {
-	return &propertyDetailsHandlers{propertyDetailsService: service, log: log}
+func NewPropertyDetailsHandlers(service PropertyDetailsService, authorizer *authz.Authorizer, log *slog.Logger) PropertyDetailsHandlers {
+	return &propertyDetailsHandlers{propertyDetailsService: service, authorizer: authorizer, log: log}
 }
 
 func (h *propertyDetailsHandlers) CreatePropertyDetails() echo.HandlerFunc {
@@ -37,13 +42,21 @@ func (h *propertyDetailsHandlers) CreatePropertyDetails() echo.HandlerFunc {
 		h.log.Info("Handling Create", slog.String("request_id", requestID))
 		details := &models.PropertyDetails{}
 		if err := utils.ReadRequest(c, details); err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
-		details, err := h.propertyDetailsService.Create(ctx, details)
+
+		// Детали ещё не существуют, поэтому проверяем владельца объекта, к которому они относятся
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+		if err := h.authorizer.CheckOwner(ctx, claims, authz.ResourceProperty, details.PropertyID); err != nil {
+			return err
+		}
+
+		details, err = h.propertyDetailsService.Create(ctx, details)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
 		return c.JSON(http.StatusOK, details)
 	}
@@ -56,14 +69,43 @@ func (h *propertyDetailsHandlers) GetPropertyDetailsById() echo.HandlerFunc {
 		h.log.Info("Handling GetPropertyDetailsById", slog.String("request_id", requestID))
 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return httpErrors.NewBadRequestError("invalid id")
 		}
 		details, err := h.propertyDetailsService.GetById(ctx, id)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
+		}
+		if utils.NotModified(c, details.Version) {
+			return c.NoContent(http.StatusNotModified)
+		}
+		return c.JSON(http.StatusOK, details)
+	}
+}
+
+func (h *propertyDetailsHandlers) PatchPropertyDetails() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling PatchPropertyDetails", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		ifMatch, err := utils.IfMatch(c)
+		if err != nil {
+			return err
+		}
+		patch, err := utils.ReadMergePatch(c, &request.PatchPropertyDetailsRequest{})
+		if err != nil {
+			return err
+		}
+
+		details, err := h.propertyDetailsService.Patch(ctx, id, patch, ifMatch)
+		if err != nil {
+			return err
 		}
+		c.Response().Header().Set(utils.HeaderETag, utils.ETag(details.Version))
 		return c.JSON(http.StatusOK, details)
 	}
 }
@@ -2,15 +2,20 @@ package http
 
 import (
 	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
 	"property-managment-service/internal/middleware"
 )
 
 type PropertyDetailsHandlers interface {
 	CreatePropertyDetails() echo.HandlerFunc
 	GetPropertyDetailsById() echo.HandlerFunc
+	PatchPropertyDetails() echo.HandlerFunc
 }
 
 func MapPropertyDetailsRoutes(propertyGroup *echo.Group, h PropertyDetailsHandlers, mw *middleware.MiddlewareManager) {
-	propertyGroup.POST("", h.CreatePropertyDetails())
+	propertyGroup.POST("", h.CreatePropertyDetails(), mw.AuthJWTMiddleware())
 	propertyGroup.GET("/:id", h.GetPropertyDetailsById())
+	// id - property_id объекта, к которому относятся детали
+	propertyGroup.PATCH("/:id", h.PatchPropertyDetails(), mw.AuthJWTMiddleware(),
+		mw.RequireOwner(authz.ResourcePropertyDetails, middleware.PathParam("id")))
 }
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"property-managment-service/internal/models"
 	"property-managment-service/internal/propdetails/service"
+	"property-managment-service/pkg/db"
 )
 
 type propDetailsRepository struct {
@@ -16,16 +19,15 @@ func NewPropDetailsRepository(db *sqlx.DB) service.PropertyDetailsRepository {
 	return &propDetailsRepository{Db: db}
 }
 
-func (r *propDetailsRepository) Create(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error) {
-	const op = "propDetailsRepository.Create"
+func (r *propDetailsRepository) CreateWithTx(ctx context.Context, details *models.PropertyDetails, tx *sqlx.Tx) (*models.PropertyDetails, error) {
+	const op = "propDetailsRepository.CreateWithTx"
 	query := `INSERT INTO property_details(property_id, floor, max_floor, area, rooms, house_creation_year, house_type, description)
 			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
-	if err := r.Db.QueryRowxContext(ctx, query, details.PropertyID, details.Floor, details.MaxFloor, details.Area,
+	if err := tx.QueryRowxContext(ctx, query, details.PropertyID, details.Floor, details.MaxFloor, details.Area,
 		details.Rooms, details.HouseCreationYear, details.HouseType, details.Description).StructScan(details); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return details, nil
-
 }
 
 func (r *propDetailsRepository) GetById(ctx context.Context, id int64) (*models.PropertyDetails, error) {
@@ -38,26 +40,46 @@ func (r *propDetailsRepository) GetById(ctx context.Context, id int64) (*models.
 	return details, nil
 }
 
-func (r *propDetailsRepository) Delete(ctx context.Context, id int64) (int64, error) {
-	const op = "propDetailsRepository.GetById"
-	var deletedId int64
-	query := `DELETE FROM property_details WHERE property_id = $1 RETURNING property_id`
-	if err := r.Db.QueryRowxContext(ctx, query, id).Scan(&deletedId); err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+func (r *propDetailsRepository) GetByIdForUpdateWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.PropertyDetails, error) {
+	const op = "propDetailsRepository.GetByIdForUpdateWithTx"
+	query := `SELECT * FROM property_details WHERE property_id = $1 FOR UPDATE`
+	details := &models.PropertyDetails{}
+	if err := tx.QueryRowxContext(ctx, query, id).StructScan(details); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return deletedId, nil
+	return details, nil
 }
 
-func (r *propDetailsRepository) Update(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error) {
-	const op = "propDetailsRepository.Update"
+func (r *propDetailsRepository) UpdateWithTx(ctx context.Context, details *models.PropertyDetails, ifMatch []int64, tx *sqlx.Tx) (*models.PropertyDetails, error) {
+	const op = "propDetailsRepository.UpdateWithTx"
 	query := `UPDATE property_details 
               SET floor = $1, max_floor = $2, area = $3, rooms = $4, 
-                  house_creation_year = $5, house_type = $6, description = $7	 
-              WHERE property_id = $8 RETURNING *`
+                  house_creation_year = $5, house_type = $6, description = $7, version = version + 1
+              WHERE property_id = $8 AND ` + db.VersionCondition(9) + ` RETURNING *`
 
-	if err := r.Db.QueryRowxContext(ctx, query,
+	if err := tx.QueryRowxContext(ctx, query,
 		details.Floor, details.MaxFloor, details.Area, details.Rooms,
-		details.HouseCreationYear, details.HouseType, details.PropertyID).StructScan(details); err != nil {
+		details.HouseCreationYear, details.HouseType, details.Description, details.PropertyID,
+		ifMatch).StructScan(details); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = db.UpdateMiss(ctx, tx, "property_details", "property_id", details.PropertyID)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return details, nil
+}
+
+// PatchWithTx обновляет только переданные колонки
+func (r *propDetailsRepository) PatchWithTx(ctx context.Context, propertyId int64, columns map[string]interface{}, ifMatch []int64, tx *sqlx.Tx) (*models.PropertyDetails, error) {
+	const op = "propDetailsRepository.PatchWithTx"
+	set, args := db.SetClause(columns, 1)
+	query := `UPDATE property_details SET ` + set + `, version = version + 1` +
+		fmt.Sprintf(` WHERE property_id = $%d AND `, len(args)+1) + db.VersionCondition(len(args)+2) + ` RETURNING *`
+	details := &models.PropertyDetails{}
+	if err := tx.QueryRowxContext(ctx, query, append(args, propertyId, ifMatch)...).StructScan(details); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = db.UpdateMiss(ctx, tx, "property_details", "property_id", propertyId)
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return details, nil
@@ -2,33 +2,72 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"log/slog"
+	audit "property-managment-service/internal/audit/service"
 	"property-managment-service/internal/models"
 	"property-managment-service/internal/propdetails/delivery/http"
+	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/utils"
 )
 
 type PropertyDetailsRepository interface {
-	Create(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error)
+	CreateWithTx(ctx context.Context, details *models.PropertyDetails, tx *sqlx.Tx) (*models.PropertyDetails, error)
 	GetById(ctx context.Context, id int64) (*models.PropertyDetails, error)
-	Update(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error)
-	Delete(ctx context.Context, id int64) (int64, error)
+	GetByIdForUpdateWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.PropertyDetails, error)
+	UpdateWithTx(ctx context.Context, details *models.PropertyDetails, ifMatch []int64, tx *sqlx.Tx) (*models.PropertyDetails, error)
+	PatchWithTx(ctx context.Context, propertyId int64, columns map[string]interface{}, ifMatch []int64, tx *sqlx.Tx) (*models.PropertyDetails, error)
 	SaveWithTx(ctx context.Context, details *models.PropertyDetails, tx *sqlx.Tx) error
 	DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error
 }
 
 type propertyDetailsService struct {
 	propertyDetailsRepository PropertyDetailsRepository
+	transactionManager        db.TransactionManager
+	audit                     *audit.AuditService
 	log                       *slog.Logger
 }
 
-func NewPropertyDetailsService(repository PropertyDetailsRepository, log *slog.Logger) http.PropertyDetailsService {
-	return &propertyDetailsService{propertyDetailsRepository: repository, log: log}
+func NewPropertyDetailsService(
+	repository PropertyDetailsRepository,
+	transactionManager db.TransactionManager,
+	audit *audit.AuditService,
+	log *slog.Logger,
+) http.PropertyDetailsService {
+	return &propertyDetailsService{
+		propertyDetailsRepository: repository,
+		transactionManager:        transactionManager,
+		audit:                     audit,
+		log:                       log,
+	}
 }
 
 func (s *propertyDetailsService) Create(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error) {
-	details, err := s.propertyDetailsRepository.Create(ctx, details)
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	details, err = s.propertyDetailsRepository.CreateWithTx(ctx, details, tx)
 	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionCreate, models.AuditEntityPropertyDetails, details.PropertyID, nil, details); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	return details, nil
@@ -43,32 +82,103 @@ func (s *propertyDetailsService) GetById(ctx context.Context, id int64) (*models
 	return details, nil
 }
 
-func (s *propertyDetailsService) Update(ctx context.Context, details *models.PropertyDetails) (*models.PropertyDetails, error) {
-	details, err := s.propertyDetailsRepository.Update(ctx, details)
+func (s *propertyDetailsService) Update(ctx context.Context, details *models.PropertyDetails, ifMatch []int64) (*models.PropertyDetails, error) {
+	details, err := s.changeWithTx(ctx, details.PropertyID, models.AuditActionUpdate, func(_ *models.PropertyDetails, tx *sqlx.Tx) (*models.PropertyDetails, error) {
+		return s.propertyDetailsRepository.UpdateWithTx(ctx, details, ifMatch, tx)
+	})
 	s.log.Info("Update", "updated details", details)
+	return details, err
+}
+
+func (s *propertyDetailsService) Patch(ctx context.Context, propertyId int64, patch *utils.MergePatch, ifMatch []int64) (*models.PropertyDetails, error) {
+	if patch.Empty() {
+		before, err := s.propertyDetailsRepository.GetById(ctx, propertyId)
+		if err != nil {
+			return nil, err
+		}
+		if err := utils.CheckVersion(ifMatch, before.Version); err != nil {
+			return nil, err
+		}
+		return before, nil
+	}
+	details, err := s.changeWithTx(ctx, propertyId, models.AuditActionUpdate, func(_ *models.PropertyDetails, tx *sqlx.Tx) (*models.PropertyDetails, error) {
+		return s.propertyDetailsRepository.PatchWithTx(ctx, propertyId, patch.Columns, ifMatch, tx)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return details, err
+	s.log.Info("Patch", "updated details", details)
+	return details, nil
 }
 
 func (s *propertyDetailsService) Delete(ctx context.Context, id int64) (int64, error) {
-	_, err := s.propertyDetailsRepository.Delete(ctx, id)
+	_, err := s.changeWithTx(ctx, id, models.AuditActionDelete, func(_ *models.PropertyDetails, tx *sqlx.Tx) (*models.PropertyDetails, error) {
+		return nil, s.propertyDetailsRepository.DeleteWithTx(ctx, id, tx)
+	})
 	s.log.Info("Delete", "details id", id)
 	if err != nil {
 		return 0, err
 	}
-	return id, err
+	return id, nil
+}
+
+// changeWithTx выполняет change в транзакции и записывает изменение в журнал той же транзакцией;
+// change возвращает состояние после изменения, nil - если детали удалены
+func (s *propertyDetailsService) changeWithTx(ctx context.Context, propertyId int64, action string, change func(before *models.PropertyDetails, tx *sqlx.Tx) (*models.PropertyDetails, error)) (*models.PropertyDetails, error) {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	before, err := s.propertyDetailsRepository.GetByIdForUpdateWithTx(ctx, propertyId, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	details, err := change(before, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.audit.RecordWithTx(ctx, tx, action, models.AuditEntityPropertyDetails, propertyId, before, details); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return details, nil
 }
 
 func (s *propertyDetailsService) SaveWithTx(ctx context.Context, details *models.PropertyDetails, tx *sqlx.Tx) error {
-	return s.propertyDetailsRepository.SaveWithTx(ctx, details, tx)
+	if err := s.propertyDetailsRepository.SaveWithTx(ctx, details, tx); err != nil {
+		return err
+	}
+	return s.audit.RecordWithTx(ctx, tx, models.AuditActionCreate, models.AuditEntityPropertyDetails, details.PropertyID, nil, details)
 }
 
+// DeleteWithTx удаляет детали объявления id; отсутствие деталей не ошибка
 func (s *propertyDetailsService) DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error {
-	err := s.propertyDetailsRepository.DeleteWithTx(ctx, id, tx)
+	before, err := s.propertyDetailsRepository.GetByIdForUpdateWithTx(ctx, id, tx)
+	if errors.Is(err, sql.ErrNoRows) {
+		// У черновика деталей может не быть
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	return nil
+	if err := s.propertyDetailsRepository.DeleteWithTx(ctx, id, tx); err != nil {
+		return err
+	}
+	return s.audit.RecordWithTx(ctx, tx, models.AuditActionDelete, models.AuditEntityPropertyDetails, id, before, nil)
 }
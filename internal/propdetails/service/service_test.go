@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	audit "property-managment-service/internal/audit/service"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/db/dbtest"
+	"property-managment-service/pkg/utils"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// fakeDetailsRepo хранит детали одного объявления
+type fakeDetailsRepo struct {
+	PropertyDetailsRepository
+	details models.PropertyDetails
+}
+
+func (r *fakeDetailsRepo) CreateWithTx(_ context.Context, details *models.PropertyDetails, _ *sqlx.Tx) (*models.PropertyDetails, error) {
+	details.Version = 1
+	return details, nil
+}
+
+func (r *fakeDetailsRepo) GetByIdForUpdateWithTx(context.Context, int64, *sqlx.Tx) (*models.PropertyDetails, error) {
+	details := r.details
+	return &details, nil
+}
+
+func (r *fakeDetailsRepo) UpdateWithTx(_ context.Context, details *models.PropertyDetails, _ []int64, _ *sqlx.Tx) (*models.PropertyDetails, error) {
+	return details, nil
+}
+
+func (r *fakeDetailsRepo) PatchWithTx(_ context.Context, _ int64, columns map[string]interface{}, _ []int64, _ *sqlx.Tx) (*models.PropertyDetails, error) {
+	details := r.details
+	details.Rooms = columns["rooms"].(int)
+	return &details, nil
+}
+
+func (r *fakeDetailsRepo) DeleteWithTx(context.Context, int64, *sqlx.Tx) error {
+	return nil
+}
+
+// fakeAuditRepo считает записи журнала; err имитирует сбой вставки
+type fakeAuditRepo struct {
+	audit.AuditRepository
+	entries []*models.AuditEntry
+	err     error
+}
+
+func (r *fakeAuditRepo) CreateWithTx(_ context.Context, entry *models.AuditEntry, _ *sqlx.Tx) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// roomsPatch разбирает merge patch так же, как обработчик PATCH
+func roomsPatch(t *testing.T) *utils.MergePatch {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, "/properties/1/details", strings.NewReader(`{"rooms": 3}`))
+	req.Header.Set(echo.HeaderContentType, utils.MIMEMergePatch)
+	patch, err := utils.ReadMergePatch(echo.New().NewContext(req, httptest.NewRecorder()), &request.PatchPropertyDetailsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patch
+}
+
+// Изменение и запись журнала коммитятся вместе: без записи в журнале изменение откатывается
+func TestChangesAreAuditedInTransaction(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		change func(s *propertyDetailsService) error
+	}{
+		{
+			name: "create",
+			change: func(s *propertyDetailsService) error {
+				_, err := s.Create(ctx, &models.PropertyDetails{PropertyID: 1, Rooms: 2})
+				return err
+			},
+		},
+		{
+			name: "update",
+			change: func(s *propertyDetailsService) error {
+				_, err := s.Update(ctx, &models.PropertyDetails{PropertyID: 1, Rooms: 3}, nil)
+				return err
+			},
+		},
+		{
+			name: "patch",
+			change: func(s *propertyDetailsService) error {
+				_, err := s.Patch(ctx, 1, roomsPatch(t), nil)
+				return err
+			},
+		},
+		{
+			name: "delete",
+			change: func(s *propertyDetailsService) error {
+				_, err := s.Delete(ctx, 1)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, auditErr := range []error{nil, errors.New("audit log is unavailable")} {
+				auditRepo := &fakeAuditRepo{err: auditErr}
+				tm := dbtest.NewTransactionManager()
+				log := slog.New(slog.NewTextHandler(io.Discard, nil))
+				repo := &fakeDetailsRepo{details: models.PropertyDetails{PropertyID: 1, Rooms: 2, Version: 1}}
+				s := NewPropertyDetailsService(repo, tm, audit.NewAuditService(auditRepo, log), log).(*propertyDetailsService)
+
+				err := tt.change(s)
+				if auditErr == nil {
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(auditRepo.entries) != 1 || tm.Commits() != 1 {
+						t.Errorf("audit entries = %d, commits = %d, want 1 and 1", len(auditRepo.entries), tm.Commits())
+					}
+					continue
+				}
+				if err == nil {
+					t.Fatal("change succeeded without an audit entry")
+				}
+				if tm.Commits() != 0 || tm.Rollbacks() != 1 {
+					t.Errorf("commits = %d, rollbacks = %d, want 0 and 1", tm.Commits(), tm.Rollbacks())
+				}
+			}
+		})
+	}
+}
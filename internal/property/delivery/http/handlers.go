@@ -6,39 +6,67 @@ import (
 	"github.com/labstack/echo/v4"
 	"log/slog"
 	"net/http"
+	"property-managment-service/internal/authz"
 	"property-managment-service/internal/config"
+	"property-managment-service/internal/middleware"
 	"property-managment-service/internal/models"
 	"property-managment-service/internal/models/request"
 	"property-managment-service/pkg/httpErrors"
 	"property-managment-service/pkg/utils"
 	"strconv"
+	"time"
 )
 
 type PropertyService interface {
 	Create(ctx context.Context, property *models.Property) (*models.Property, error)
 	GetById(ctx context.Context, id int64) (*models.Property, error)
-	GetByOwnerId(ctx context.Context, id int64) ([]*models.Property, error)
+	// GetByOwnerId без includeUnpublished возвращает только опубликованные объявления
+	GetByOwnerId(ctx context.Context, id int64, includeUnpublished bool) ([]*models.Property, error)
+	GetArchivedByOwnerId(ctx context.Context, id int64) ([]*models.Property, error)
+	GetArchivedBefore(ctx context.Context, before time.Time, exclude []int64, limit int) ([]int64, error)
+	// Delete архивирует объявление; строку удаляет DeleteWithTx при очистке архива
 	Delete(ctx context.Context, id int64) (int64, error)
-	Update(ctx context.Context, property *models.Property) (*models.Property, error)
+	Restore(ctx context.Context, id int64) (*models.Property, error)
+	Submit(ctx context.Context, id int64) (*models.Property, error)
+	Approve(ctx context.Context, id int64) (*models.Property, error)
+	Suspend(ctx context.Context, id int64, reason string) (*models.Property, error)
+	GetByStatus(ctx context.Context, status string) ([]*models.Property, error)
+	Update(ctx context.Context, property *models.Property, ifMatch []int64) (*models.Property, error)
+	Patch(ctx context.Context, id int64, patch *utils.MergePatch, ifMatch []int64) (*models.Property, error)
 	SaveWithTx(ctx context.Context, property *models.Property, tx *sqlx.Tx) error
 	DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error
-	GetAll(ctx context.Context) ([]*models.Property, error)
+	List(ctx context.Context, req *request.PropertyListRequest) (*models.PropertyPage, error)
+	Search(ctx context.Context, req *request.PropertySearchRequest) (*models.PropertySearchPage, error)
 }
 
 type PropertyFormService interface {
 	SavePropertyForm(ctx context.Context, form *request.AddPropertyRequest) error
 	DeletePropertyForm(ctx context.Context, propertyID int64) error
+	PurgeArchived(ctx context.Context, before time.Time) (int, error)
 }
 
 type propertyHandlers struct {
 	propertyService     PropertyService
 	propertyServiceForm PropertyFormService
+	authorizer          *authz.Authorizer
 	cfg                 *config.Config
 	log                 *slog.Logger
 }
 
-func NewPropertyHandlers(propertyService PropertyService, propertyServiceForm PropertyFormService, cfg *config.Config, log *slog.Logger) PropertyHandlers {
-	return &propertyHandlers{propertyService: propertyService, propertyServiceForm: propertyServiceForm, cfg: cfg, log: log}
+func NewPropertyHandlers(
+	propertyService PropertyService,
+	propertyServiceForm PropertyFormService,
+	authorizer *authz.Authorizer,
+	cfg *config.Config,
+	log *slog.Logger,
+) PropertyHandlers {
+	return &propertyHandlers{
+		propertyService:     propertyService,
+		propertyServiceForm: propertyServiceForm,
+		authorizer:          authorizer,
+		cfg:                 cfg,
+		log:                 log,
+	}
 }
 
 func (h *propertyHandlers) CreateProperty() echo.HandlerFunc {
@@ -49,22 +77,22 @@ func (h *propertyHandlers) CreateProperty() echo.HandlerFunc {
 		property := &models.Property{}
 
 		if err := utils.ReadRequest(c, property); err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
 
-		userClaims := c.Get("user").(map[string]interface{})
-		userIdFromClaims := userClaims["uid"].(float64)
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
 
-		if (int64(userIdFromClaims)) != property.OwnerId {
-			utils.LogResponseError(c, h.log, httpErrors.Unauthorized)
-			return c.JSON(http.StatusUnauthorized, httpErrors.Unauthorized)
+		// Администратор может создать объявление от имени другого владельца
+		if claims.UserId != property.OwnerId && !claims.IsAdmin() {
+			return httpErrors.NewForbiddenError(nil)
 		}
 
-		property, err := h.propertyService.Create(ctx, property)
+		property, err = h.propertyService.Create(ctx, property)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
 		return c.JSON(http.StatusCreated, property)
 	}
@@ -81,39 +109,84 @@ func (h *propertyHandlers) GetProperties() echo.HandlerFunc {
 			// Логика для поиска по property_id
 			id, err := strconv.ParseInt(idParam, 10, 64)
 			if err != nil {
-				utils.LogResponseError(c, h.log, httpErrors.NewBadRequestError("invalid id"))
-				return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid id"))
+				return httpErrors.NewBadRequestError("invalid id")
 			}
 
 			property, err := h.propertyService.GetById(ctx, id)
 			if err != nil {
-				utils.LogResponseError(c, h.log, err)
-				return c.JSON(httpErrors.ErrorResponse(err))
+				return err
+			}
+			// Архивное объявление видят только владелец и администратор
+			if property.DeletedAt != nil && !isOwnerOrAdmin(c, property.OwnerId) {
+				return httpErrors.NewNotFoundError("property not found")
+			}
+			if property.Status != models.PropertyStatusPublished && !canSeeUnpublished(c, property.OwnerId) {
+				return httpErrors.NewNotFoundError("property not found")
+			}
+			if utils.NotModified(c, property.Version) {
+				return c.NoContent(http.StatusNotModified)
 			}
 			return c.JSON(http.StatusOK, property)
 		} else if ownerIdParam := c.QueryParam("ownerId"); ownerIdParam != "" {
 			// Логика для поиска по owner_id
 			ownerId, err := strconv.ParseInt(ownerIdParam, 10, 64)
 			if err != nil {
-				utils.LogResponseError(c, h.log, httpErrors.NewBadRequestError("invalid ownerId"))
-				return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid ownerId"))
+				return httpErrors.NewBadRequestError("invalid ownerId")
 			}
 
-			properties, err := h.propertyService.GetByOwnerId(ctx, ownerId)
+			properties, err := h.propertyService.GetByOwnerId(ctx, ownerId, canSeeUnpublished(c, ownerId))
 			if err != nil {
-				utils.LogResponseError(c, h.log, err)
-				return c.JSON(httpErrors.ErrorResponse(err))
+				return err
 			}
 			return c.JSON(http.StatusOK, properties)
 		} else {
-			// Если параметры id и ownerId не переданы, возвращаем все записи
-			properties, err := h.propertyService.GetAll(ctx)
+			// Если параметры id и ownerId не переданы, возвращаем страницу с учётом фильтров и сортировки
+			r := &request.PropertyListRequest{}
+			if err := utils.ReadRequest(c, r); err != nil {
+				return err
+			}
+
+			page, err := h.propertyService.List(ctx, r)
 			if err != nil {
-				utils.LogResponseError(c, h.log, err)
-				return c.JSON(httpErrors.ErrorResponse(err))
+				return err
 			}
-			return c.JSON(http.StatusOK, properties)
+			return c.JSON(http.StatusOK, page)
+		}
+	}
+}
+
+// canSeeUnpublished разрешает видеть черновики и снятые с публикации объявления владельцу,
+// администратору и сервисам с API-ключом
+func canSeeUnpublished(c echo.Context, ownerId int64) bool {
+	if _, ok := middleware.GetApiKey(c); ok {
+		return true
+	}
+	return isOwnerOrAdmin(c, ownerId)
+}
+
+func isOwnerOrAdmin(c echo.Context, ownerId int64) bool {
+	claims, err := middleware.GetClaims(c)
+	if err != nil {
+		return false
+	}
+	return claims.UserId == ownerId || claims.IsAdmin()
+}
+
+func (h *propertyHandlers) SearchProperties() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling SearchProperties", slog.String("request_id", requestID))
+		r := &request.PropertySearchRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
 		}
+
+		page, err := h.propertyService.Search(ctx, r)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, page)
 	}
 }
 
@@ -124,119 +197,195 @@ func (h *propertyHandlers) DeleteProperty() echo.HandlerFunc {
 		h.log.Info("Handling DeleteProperty", slog.String("request_id", requestID))
 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return httpErrors.NewBadRequestError("invalid id")
 		}
 		deletedId, err := h.propertyService.Delete(ctx, id)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
 		return c.JSON(http.StatusOK, deletedId)
 	}
 }
 
-func (h *propertyHandlers) UpdateProperty() echo.HandlerFunc {
+func (h *propertyHandlers) RestoreProperty() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx := utils.GetRequestCtx(c)
 		requestID := utils.GetRequestID(c)
-		h.log.Info("Handling UpdateProperty", slog.String("request_id", requestID))
-		property := &models.Property{}
-		if err := utils.ReadRequest(c, property); err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+		h.log.Info("Handling RestoreProperty", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
 		}
-		property, err := h.propertyService.Update(ctx, property)
+
+		property, err := h.propertyService.Restore(ctx, id)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
+		c.Response().Header().Set(utils.HeaderETag, utils.ETag(property.Version))
 		return c.JSON(http.StatusOK, property)
 	}
 }
 
-func (h *propertyHandlers) SavePropertyForm() echo.HandlerFunc {
+// SubmitProperty отправляет черновик на проверку администратору
+func (h *propertyHandlers) SubmitProperty() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx := utils.GetRequestCtx(c)
 		requestID := utils.GetRequestID(c)
-		h.log.Info("Handling SavePropertyForm", slog.String("request_id", requestID))
-		r := &request.AddPropertyRequest{}
+		h.log.Info("Handling SubmitProperty", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
 
-		if err := utils.ReadRequest(c, r); err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+		property, err := h.propertyService.Submit(ctx, id)
+		if err != nil {
+			return err
 		}
+		c.Response().Header().Set(utils.HeaderETag, utils.ETag(property.Version))
+		return c.JSON(http.StatusOK, property)
+	}
+}
 
-		userClaims := c.Get("user").(map[string]interface{})
-		userIdFromClaims := userClaims["uid"].(float64)
+// GetArchivedProperties возвращает архив текущего пользователя; администратор может указать ownerId
+func (h *propertyHandlers) GetArchivedProperties() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetArchivedProperties", slog.String("request_id", requestID))
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
 
-		r.Property.OwnerId = int64(userIdFromClaims)
+		ownerId := claims.UserId
+		if ownerIdParam := c.QueryParam("ownerId"); ownerIdParam != "" {
+			ownerId, err = strconv.ParseInt(ownerIdParam, 10, 64)
+			if err != nil {
+				return httpErrors.NewBadRequestError("invalid ownerId")
+			}
+			if ownerId != claims.UserId && !claims.IsAdmin() {
+				return httpErrors.NewForbiddenError(nil)
+			}
+		}
 
-		err := h.propertyServiceForm.SavePropertyForm(ctx, r)
+		properties, err := h.propertyService.GetArchivedByOwnerId(ctx, ownerId)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": err.Error(),
-			})
+			return err
 		}
+		return c.JSON(http.StatusOK, properties)
+	}
+}
 
-		return c.JSON(http.StatusCreated, map[string]string{
-			"message": "Property form saved successfully",
-		})
+func (h *propertyHandlers) UpdateProperty() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling UpdateProperty", slog.String("request_id", requestID))
+		property := &models.Property{}
+		if err := utils.ReadRequest(c, property); err != nil {
+			return err
+		}
+
+		// id объекта приходит в теле, поэтому владельца проверяем здесь, а не в middleware
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+		if err := h.authorizer.CheckOwner(ctx, claims, authz.ResourceProperty, property.ID); err != nil {
+			return err
+		}
+
+		ifMatch, err := utils.IfMatch(c)
+		if err != nil {
+			return err
+		}
 
+		property, err = h.propertyService.Update(ctx, property, ifMatch)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(utils.HeaderETag, utils.ETag(property.Version))
+		return c.JSON(http.StatusOK, property)
 	}
 }
 
-func (h *propertyHandlers) DeletePropertyForm() echo.HandlerFunc {
+func (h *propertyHandlers) PatchProperty() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx := utils.GetRequestCtx(c)
 		requestID := utils.GetRequestID(c)
-		h.log.Info("Handling DeleteProperty", slog.String("request_id", requestID))
+		h.log.Info("Handling PatchProperty", slog.String("request_id", requestID))
 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return httpErrors.NewBadRequestError("invalid id")
 		}
 
-		userClaims := c.Get("user").(map[string]interface{})
-		userIdFromClaims := userClaims["uid"].(float64)
+		ifMatch, err := utils.IfMatch(c)
+		if err != nil {
+			return err
+		}
+		patch, err := utils.ReadMergePatch(c, &request.PatchPropertyRequest{})
+		if err != nil {
+			return err
+		}
 
-		property, err := h.propertyService.GetById(ctx, id)
+		property, err := h.propertyService.Patch(ctx, id, patch, ifMatch)
 		if err != nil {
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
+		c.Response().Header().Set(utils.HeaderETag, utils.ETag(property.Version))
+		return c.JSON(http.StatusOK, property)
+	}
+}
+
+func (h *propertyHandlers) SavePropertyForm() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling SavePropertyForm", slog.String("request_id", requestID))
+		r := &request.AddPropertyRequest{}
 
-		if (int64(userIdFromClaims)) != property.OwnerId {
-			utils.LogResponseError(c, h.log, httpErrors.Unauthorized)
-			return c.JSON(http.StatusUnauthorized, httpErrors.Unauthorized)
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
 		}
 
-		err = h.propertyServiceForm.DeletePropertyForm(ctx, id)
+		claims, err := middleware.GetClaims(c)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": err.Error(),
-			})
+			return httpErrors.NewUnauthorizedError(err)
 		}
 
-		return c.JSON(http.StatusCreated, map[string]string{
-			"message": "Property removed successfully",
+		r.Property.OwnerId = claims.UserId
+
+		err = h.propertyServiceForm.SavePropertyForm(ctx, r)
+		if err != nil {
+			return err
+		}
+
+		// Объявление сохраняется черновиком; id нужен, чтобы дополнить его и отправить на проверку
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"message": "Property form saved successfully",
+			"id":      r.Property.ID,
 		})
+
 	}
 }
 
-func (h *propertyHandlers) GetAllProperties() echo.HandlerFunc {
+func (h *propertyHandlers) DeletePropertyForm() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx := utils.GetRequestCtx(c)
 		requestID := utils.GetRequestID(c)
-		h.log.Info("Handling GetAllProperties", slog.String("request_id", requestID))
+		h.log.Info("Handling DeleteProperty", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
 
-		// Получаем все записи недвижимости через сервис
-		properties, err := h.propertyService.GetAll(ctx)
+		err = h.propertyServiceForm.DeletePropertyForm(ctx, id)
 		if err != nil {
-			utils.LogResponseError(c, h.log, err)
-			return c.JSON(httpErrors.ErrorResponse(err))
+			return err
 		}
 
-		// Отправляем данные в ответе
-		return c.JSON(http.StatusOK, properties)
+		return c.JSON(http.StatusCreated, map[string]string{
+			"message": "Property archived successfully",
+		})
 	}
 }
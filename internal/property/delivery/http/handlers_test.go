@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/httpErrors"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const ownerId = 10
+
+type fakePropertyService struct {
+	PropertyService
+	property *models.Property
+}
+
+func (s *fakePropertyService) GetById(_ context.Context, id int64) (*models.Property, error) {
+	property := *s.property
+	property.ID = id
+	return &property, nil
+}
+
+func TestGetPropertyByIdVisibility(t *testing.T) {
+	archivedAt := time.Now()
+	published := &models.Property{OwnerId: ownerId, Status: models.PropertyStatusPublished}
+	archived := &models.Property{OwnerId: ownerId, Status: models.PropertyStatusPublished, DeletedAt: &archivedAt}
+	draft := &models.Property{OwnerId: ownerId, Status: models.PropertyStatusDraft}
+
+	owner := authz.NewClaims(ownerId, []string{authz.RoleHost}, time.Now().Add(time.Hour))
+	stranger := authz.NewClaims(20, []string{authz.RoleGuest}, time.Now().Add(time.Hour))
+	admin := authz.NewClaims(30, []string{authz.RoleAdmin}, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name     string
+		property *models.Property
+		claims   *authz.Claims
+		want     int
+	}{
+		{name: "published, anonymous", property: published, want: http.StatusOK},
+		{name: "archived, anonymous", property: archived, want: http.StatusNotFound},
+		{name: "archived, another user", property: archived, claims: stranger, want: http.StatusNotFound},
+		{name: "archived, owner", property: archived, claims: owner, want: http.StatusOK},
+		{name: "archived, admin", property: archived, claims: admin, want: http.StatusOK},
+		{name: "draft, anonymous", property: draft, want: http.StatusNotFound},
+		{name: "draft, owner", property: draft, claims: owner, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &propertyHandlers{
+				propertyService: &fakePropertyService{property: tt.property},
+				log:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/properties?id=1", nil)
+			if tt.claims != nil {
+				req = req.WithContext(authz.WithClaims(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			status := rec.Code
+			if err := h.GetProperties()(c); err != nil {
+				var restErr httpErrors.RestErr
+				if !errors.As(err, &restErr) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				status = restErr.Status()
+			}
+			if status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
@@ -2,24 +2,39 @@ package http
 
 import (
 	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
 	"property-managment-service/internal/middleware"
 )
 
 type PropertyHandlers interface {
 	CreateProperty() echo.HandlerFunc
 	GetProperties() echo.HandlerFunc
+	SearchProperties() echo.HandlerFunc
 	DeleteProperty() echo.HandlerFunc
+	RestoreProperty() echo.HandlerFunc
+	SubmitProperty() echo.HandlerFunc
+	GetArchivedProperties() echo.HandlerFunc
 	UpdateProperty() echo.HandlerFunc
+	PatchProperty() echo.HandlerFunc
 	SavePropertyForm() echo.HandlerFunc
 	DeletePropertyForm() echo.HandlerFunc
 }
 
 func MapPropertyRoutes(propertyGroup *echo.Group, h PropertyHandlers, mw *middleware.MiddlewareManager) {
-	propertyGroup.POST("", h.CreateProperty(), mw.AuthJWTMiddleware())
-	propertyGroup.GET("", h.GetProperties())
-	propertyGroup.DELETE("/:id", h.DeleteProperty(), mw.AuthJWTMiddleware())
+	host := mw.RequireRole(authz.RoleHost)
+	propertyGroup.POST("", h.CreateProperty(), mw.AuthJWTMiddleware(), host)
+	read := mw.APIKeyOr(authz.ScopePropertiesRead, nil)
+	// Владелец и администратор видят через GET и неопубликованные объявления, поэтому токен разбирается, если он есть
+	propertyGroup.GET("", h.GetProperties(), mw.APIKeyOr(authz.ScopePropertiesRead, mw.OptionalAuthJWTMiddleware()))
+	propertyGroup.GET("/search", h.SearchProperties(), read)
+	owner := mw.RequireOwner(authz.ResourceProperty, middleware.PathParam("id"))
+	write := mw.APIKeyOr(authz.ScopePropertiesWrite, mw.AuthJWTMiddleware())
+	propertyGroup.GET("/archived", h.GetArchivedProperties(), mw.AuthJWTMiddleware())
+	propertyGroup.DELETE("/:id", h.DeleteProperty(), write, owner)
+	propertyGroup.POST("/:id/restore", h.RestoreProperty(), write, owner)
+	propertyGroup.POST("/:id/submit", h.SubmitProperty(), write, owner)
 	propertyGroup.PUT("", h.UpdateProperty(), mw.AuthJWTMiddleware())
-	propertyGroup.POST("/form", h.SavePropertyForm(), mw.AuthJWTMiddleware())
-	propertyGroup.DELETE("/form/:id", h.DeletePropertyForm(), mw.AuthJWTMiddleware())
-
+	propertyGroup.PATCH("/:id", h.PatchProperty(), write, owner)
+	propertyGroup.POST("/form", h.SavePropertyForm(), mw.AuthJWTMiddleware(), host)
+	propertyGroup.DELETE("/form/:id", h.DeletePropertyForm(), mw.AuthJWTMiddleware(), owner)
 }
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"property-managment-service/internal/models"
+	"strconv"
+	"strings"
+)
+
+// sortColumns сопоставляет поле сортировки API колонке выборки и типу для приведения значения курсора
+var sortColumns = map[string]struct {
+	column string
+	cast   string
+}{
+	"price":     {column: "price", cast: "int"},
+	"createdAt": {column: "created_at", cast: "date"},
+	"rating":    {column: "rating", cast: "numeric"},
+	"relevance": {column: "rank", cast: "real"},
+	"distance":  {column: "distance", cast: "float8"},
+}
+
+// coverColumns и coverJoin добавляют к выборке обложку объявления (вариант medium, если он есть)
+const (
+	coverColumns = `ci.id AS cover_image_id, COALESCE(ci.variants->'medium'->>'key', ci.image_url) AS cover_image_key`
+	coverJoin    = ` LEFT JOIN properties_images ci ON ci.property_id = p.id AND ci.is_cover`
+)
+
+// ts_headline выделяет совпадения управляющими символами, а не тегами: исходный текст экранируется
+// в Go (highlight), и только после этого метки заменяются на <b> и </b>
+const (
+	headlineStart   = "\x02"
+	headlineStop    = "\x03"
+	headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+var headlineReplacer = strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>")
+
+// highlight экранирует фрагмент ts_headline как HTML и превращает метки совпадений в теги <b>
+func highlight(fragment string) string {
+	return headlineReplacer.Replace(html.EscapeString(fragment))
+}
+
+// queryBuilder собирает WHERE с позиционными параметрами
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// whereClause возвращает накопленные условия и сбрасывает их, сохраняя параметры
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	clause := " WHERE " + strings.Join(b.conditions, " AND ")
+	b.conditions = nil
+	return clause
+}
+
+func (b *queryBuilder) applyFilter(filter models.PropertyFilter) {
+	if filter.PropertyType != "" {
+		b.where("p.property_type = " + b.arg(filter.PropertyType))
+	}
+	if filter.RentalType != "" {
+		b.where("p.rental_type = " + b.arg(filter.RentalType))
+	}
+	if filter.MinPrice > 0 {
+		b.where("p.price >= " + b.arg(filter.MinPrice))
+	}
+	if filter.MaxPrice > 0 {
+		b.where("p.price <= " + b.arg(filter.MaxPrice))
+	}
+	if filter.MinGuests > 0 {
+		b.where("p.max_guests >= " + b.arg(filter.MinGuests))
+	}
+	if filter.MinRooms > 0 {
+		b.where("d.rooms >= " + b.arg(filter.MinRooms))
+	}
+	if filter.MaxRooms > 0 {
+		b.where("d.rooms <= " + b.arg(filter.MaxRooms))
+	}
+	if filter.MinArea > 0 {
+		b.where("d.area >= " + b.arg(filter.MinArea))
+	}
+	if filter.MaxArea > 0 {
+		b.where("d.area <= " + b.arg(filter.MaxArea))
+	}
+	if filter.BBox != nil {
+		b.where(fmt.Sprintf("p.latitude BETWEEN %s AND %s AND p.longitude BETWEEN %s AND %s",
+			b.arg(filter.BBox.MinLatitude), b.arg(filter.BBox.MaxLatitude),
+			b.arg(filter.BBox.MinLongitude), b.arg(filter.BBox.MaxLongitude)))
+	}
+}
+
+// distanceColumn возвращает выражение для колонки distance и, если задан радиус, ограничивает им выборку.
+// earth_box отбирает кандидатов по индексу properties_earth_idx, earth_distance отсекает углы квадрата.
+func (b *queryBuilder) distanceColumn(filter models.PropertyFilter) string {
+	if filter.Near == nil {
+		return "NULL::float8 AS distance"
+	}
+
+	point := fmt.Sprintf("ll_to_earth(%s, %s)", b.arg(filter.Near.Latitude), b.arg(filter.Near.Longitude))
+	distance := "earth_distance(ll_to_earth(p.latitude, p.longitude), " + point + ")"
+	b.where("p.latitude IS NOT NULL")
+	if filter.RadiusMeters > 0 {
+		radius := b.arg(filter.RadiusMeters)
+		b.where(fmt.Sprintf("earth_box(%s, %s) @> ll_to_earth(p.latitude, p.longitude)", point, radius))
+		b.where(fmt.Sprintf("%s <= %s", distance, radius))
+	}
+	return distance + " AS distance"
+}
+
+// applyKeyset ограничивает выборку записями после курсора
+func (b *queryBuilder) applyKeyset(query *models.PropertyListQuery, alias string) error {
+	sort, ok := sortColumns[query.SortField]
+	if !ok {
+		return fmt.Errorf("unknown sort field %q", query.SortField)
+	}
+
+	comparison := ">"
+	if query.Desc {
+		comparison = "<"
+	}
+
+	if query.AfterValue != nil {
+		b.where(fmt.Sprintf("(%s.%s, %s.id) %s (%s::%s, %s)",
+			alias, sort.column, alias, comparison, b.arg(*query.AfterValue), sort.cast, b.arg(query.AfterId)))
+	}
+	return nil
+}
+
+func orderBy(query *models.PropertyListQuery, alias string) string {
+	direction := "ASC"
+	if query.Desc {
+		direction = "DESC"
+	}
+	column := sortColumns[query.SortField].column
+	return fmt.Sprintf(" ORDER BY %s.%s %s, %s.id %s", alias, column, direction, alias, direction)
+}
+
+// List возвращает до query.Limit объявлений, удовлетворяющих фильтрам, в порядке сортировки
+func (r *propertyRepository) List(ctx context.Context, query *models.PropertyListQuery) ([]*models.Property, error) {
+	const op = "propertyRepository.List"
+
+	if query.SortField == "relevance" {
+		return nil, fmt.Errorf("%s: sort by relevance requires a search query", op)
+	}
+
+	b := &queryBuilder{}
+	b.where("p.deleted_at IS NULL")
+	b.where("p.status = " + b.arg(models.PropertyStatusPublished))
+	distance := b.distanceColumn(query.Filter)
+	b.applyFilter(query.Filter)
+	matched := `SELECT p.*, ` + distance + `, ` + coverColumns + `
+				FROM properties p
+				LEFT JOIN property_details d ON d.property_id = p.id` + coverJoin + b.whereClause()
+
+	if err := b.applyKeyset(query, "r"); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sql := `SELECT r.* FROM (` + matched + `) r` + b.whereClause() + orderBy(query, "r") + ` LIMIT ` + b.arg(query.Limit)
+
+	rows, err := r.Db.QueryxContext(ctx, sql, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	properties := []*models.Property{}
+	for rows.Next() {
+		property := &models.Property{}
+		if err := rows.StructScan(property); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		properties = append(properties, property)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return properties, nil
+}
+
+// Search выполняет полнотекстовый поиск по property_search с фильтрами и keyset-пагинацией.
+// Фрагменты с подсветкой строятся только для записей итоговой страницы.
+func (r *propertyRepository) Search(ctx context.Context, query *models.PropertyListQuery, text string) ([]*models.PropertySearchResult, error) {
+	const op = "propertyRepository.Search"
+
+	b := &queryBuilder{}
+	tsQuery := fmt.Sprintf("websearch_to_tsquery('russian', %s)", b.arg(text))
+
+	b.where("s.document @@ " + tsQuery)
+	b.where("p.deleted_at IS NULL")
+	b.where("p.status = " + b.arg(models.PropertyStatusPublished))
+	distance := b.distanceColumn(query.Filter)
+	b.applyFilter(query.Filter)
+	matched := `SELECT p.*, ts_rank_cd(s.document, ` + tsQuery + `) AS rank, ` + distance + `, ` + coverColumns + `
+				FROM properties p
+				JOIN property_search s ON s.property_id = p.id
+				LEFT JOIN property_details d ON d.property_id = p.id` + coverJoin + b.whereClause()
+
+	if err := b.applyKeyset(query, "r"); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Метки, уже встречающиеся в тексте, вырезаются, чтобы их нельзя было выдать за подсветку
+	options, sentinels := b.arg(headlineOptions), b.arg(headlineStart+headlineStop)
+	sql := `SELECT page.*,
+				   ts_headline('russian', translate(page.title, ` + sentinels + `, ''), ` + tsQuery + `, ` + options + `) AS title_highlight,
+				   ts_headline('russian', translate(coalesce(d.description, ''), ` + sentinels + `, ''), ` + tsQuery + `, ` + options + `) AS description_highlight
+			FROM (SELECT r.* FROM (` + matched + `) r` + b.whereClause() + orderBy(query, "r") + ` LIMIT ` + b.arg(query.Limit) + `) page
+			LEFT JOIN property_details d ON d.property_id = page.id` + orderBy(query, "page")
+
+	rows, err := r.Db.QueryxContext(ctx, sql, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	results := []*models.PropertySearchResult{}
+	for rows.Next() {
+		result := &models.PropertySearchResult{}
+		if err := rows.StructScan(result); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		result.TitleHighlight = highlight(result.TitleHighlight)
+		result.DescriptionHighlight = highlight(result.DescriptionHighlight)
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{name: "plain", fragment: "Квартира у моря", want: "Квартира у моря"},
+		{name: "match", fragment: "Квартира у \x02моря\x03", want: "Квартира у <b>моря</b>"},
+		{
+			name:     "markup in source is escaped",
+			fragment: "<script>alert(1)</script> \x02дом\x03 <b onmouseover=\"x\">",
+			want:     "&lt;script&gt;alert(1)&lt;/script&gt; <b>дом</b> &lt;b onmouseover=&#34;x&#34;&gt;",
+		},
+		{name: "entities", fragment: "Tom & Jerry's \x02loft\x03", want: "Tom &amp; Jerry&#39;s <b>loft</b>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.fragment); got != tt.want {
+				t.Errorf("highlight(%q) = %q, want %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
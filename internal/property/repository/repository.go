@@ -2,10 +2,15 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"property-managment-service/internal/models"
 	"property-managment-service/internal/property/service"
+	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/httpErrors"
+	"time"
 )
 
 type propertyRepository struct {
@@ -16,17 +21,6 @@ func NewPropertyRepository(db *sqlx.DB) service.PropertyRepository {
 	return &propertyRepository{Db: db}
 }
 
-func (r *propertyRepository) Create(ctx context.Context, property *models.Property) (*models.Property, error) {
-	const op = "propertyRepository.create"
-	query := `INSERT INTO properties (owner_id, title, location, price, property_type, rental_type, max_guests, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
-	if err := r.Db.QueryRowxContext(ctx, query, &property.OwnerId, &property.Title, &property.Location, &property.Price,
-		&property.PropertyType, &property.RentalType, &property.MaxGuests, &property.CreatedAt).StructScan(property); err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	return property, nil
-}
-
 func (r *propertyRepository) GetById(ctx context.Context, id int64) (*models.Property, error) {
 	const op = "propertyRepository.getById"
 	query := `SELECT * FROM properties WHERE id = $1`
@@ -37,10 +31,33 @@ func (r *propertyRepository) GetById(ctx context.Context, id int64) (*models.Pro
 	return property, nil
 }
 
-func (r *propertyRepository) GetByOwnerId(ctx context.Context, id int64) ([]*models.Property, error) {
+// GetByOwnerId возвращает объявления владельца, кроме архивных; неопубликованные - только при includeUnpublished
+func (r *propertyRepository) GetByOwnerId(ctx context.Context, id int64, includeUnpublished bool) ([]*models.Property, error) {
 	const op = "propertyRepository.getByOwnerId"
-	query := `SELECT * FROM properties WHERE owner_id = $1`
-	rows, err := r.Db.QueryxContext(ctx, query, id)
+	query := `SELECT p.*, ` + coverColumns + ` FROM properties p` + coverJoin + `
+			  WHERE p.owner_id = $1 AND p.deleted_at IS NULL AND ($2 OR p.status = $3)`
+	return r.selectProperties(ctx, op, query, id, includeUnpublished, models.PropertyStatusPublished)
+}
+
+// GetByStatus возвращает неархивные объявления в статусе status, старые первыми
+func (r *propertyRepository) GetByStatus(ctx context.Context, status string) ([]*models.Property, error) {
+	const op = "propertyRepository.GetByStatus"
+	query := `SELECT p.*, ` + coverColumns + ` FROM properties p` + coverJoin + `
+			  WHERE p.status = $1 AND p.deleted_at IS NULL
+			  ORDER BY p.id`
+	return r.selectProperties(ctx, op, query, status)
+}
+
+func (r *propertyRepository) GetArchivedByOwnerId(ctx context.Context, id int64) ([]*models.Property, error) {
+	const op = "propertyRepository.GetArchivedByOwnerId"
+	query := `SELECT p.*, ` + coverColumns + ` FROM properties p` + coverJoin + `
+			  WHERE p.owner_id = $1 AND p.deleted_at IS NOT NULL
+			  ORDER BY p.deleted_at DESC`
+	return r.selectProperties(ctx, op, query, id)
+}
+
+func (r *propertyRepository) selectProperties(ctx context.Context, op string, query string, args ...interface{}) ([]*models.Property, error) {
+	rows, err := r.Db.QueryxContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -62,40 +79,144 @@ func (r *propertyRepository) GetByOwnerId(ctx context.Context, id int64) ([]*mod
 	return properties, nil
 }
 
-func (r *propertyRepository) Update(ctx context.Context, property *models.Property) (*models.Property, error) {
-	const op = "propertyRepository.update"
+func (r *propertyRepository) UpdateWithTx(ctx context.Context, property *models.Property, ifMatch []int64, tx *sqlx.Tx) (*models.Property, error) {
+	const op = "propertyRepository.UpdateWithTx"
 	query := `UPDATE properties 
               SET title = $1, location = $2, price = $3, property_type = $4, 
-                  rental_type = $5, max_guests = $6 
-              WHERE id = $7 RETURNING *`
+                  rental_type = $5, max_guests = $6, latitude = $7, longitude = $8, version = version + 1
+              WHERE id = $9 AND deleted_at IS NULL AND ` + db.VersionCondition(10) + ` RETURNING *`
 
-	if err := r.Db.QueryRowxContext(ctx, query,
+	if err := tx.QueryRowxContext(ctx, query,
 		property.Title, property.Location, property.Price, property.PropertyType,
-		property.RentalType, property.MaxGuests, property.ID).StructScan(property); err != nil {
+		property.RentalType, property.MaxGuests, property.Latitude, property.Longitude, property.ID,
+		ifMatch).StructScan(property); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = r.updateMiss(ctx, tx, property.ID)
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return property, nil
 }
 
-func (r *propertyRepository) Delete(ctx context.Context, id int64) (int64, error) {
-	const op = "propertyRepository.delete"
-	query := `DELETE FROM properties WHERE id = $1 RETURNING id`
+// PatchWithTx обновляет только переданные колонки
+func (r *propertyRepository) PatchWithTx(ctx context.Context, id int64, columns map[string]interface{}, ifMatch []int64, tx *sqlx.Tx) (*models.Property, error) {
+	const op = "propertyRepository.PatchWithTx"
+	set, args := db.SetClause(columns, 1)
+	query := `UPDATE properties SET ` + set + `, version = version + 1` +
+		fmt.Sprintf(` WHERE id = $%d AND deleted_at IS NULL AND `, len(args)+1) + db.VersionCondition(len(args)+2) + ` RETURNING *`
+	property := &models.Property{}
+	if err := tx.QueryRowxContext(ctx, query, append(args, id, ifMatch)...).StructScan(property); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = r.updateMiss(ctx, tx, id)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return property, nil
+}
 
-	var deletedID int64
-	// Выполняем DELETE и возвращаем id удалённой записи
-	err := r.Db.QueryRowxContext(ctx, query, id).Scan(&deletedID)
-	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+// updateMiss объясняет, почему UPDATE не затронул строку: объявления нет (sql.ErrNoRows),
+// оно в архиве (409) или не совпала версия (412)
+func (r *propertyRepository) updateMiss(ctx context.Context, q sqlx.QueryerContext, id int64) error {
+	var archived bool
+	if err := sqlx.GetContext(ctx, q, &archived, `SELECT deleted_at IS NOT NULL FROM properties WHERE id = $1`, id); err != nil {
+		return err
+	}
+	if archived {
+		return httpErrors.NewConflictError("property is archived")
+	}
+	return db.UpdateMiss(ctx, q, "properties", "id", id)
+}
+
+func (r *propertyRepository) GetByIdForUpdateWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Property, error) {
+	const op = "propertyRepository.GetByIdForUpdateWithTx"
+	query := `SELECT * FROM properties WHERE id = $1 FOR UPDATE`
+	property := &models.Property{}
+	if err := tx.QueryRowxContext(ctx, query, id).StructScan(property); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return property, nil
+}
+
+// HasUpcomingBookingsWithTx проверяет, есть ли подтверждённые брони, которые ещё не закончились
+func (r *propertyRepository) HasUpcomingBookingsWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (bool, error) {
+	const op = "propertyRepository.HasUpcomingBookingsWithTx"
+	query := `SELECT EXISTS (
+				SELECT 1 FROM bookings
+				WHERE property_id = $1 AND status = 'confirmed' AND check_out_date > CURRENT_DATE
+			  )`
+	var exists bool
+	if err := tx.QueryRowxContext(ctx, query, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exists, nil
+}
+
+// GetCompletenessWithTx сообщает, заполнены ли детали объявления и сколько у него изображений
+func (r *propertyRepository) GetCompletenessWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (bool, int, error) {
+	const op = "propertyRepository.GetCompletenessWithTx"
+	query := `SELECT EXISTS (SELECT 1 FROM property_details WHERE property_id = $1),
+					 (SELECT count(*) FROM properties_images WHERE property_id = $1)`
+	var hasDetails bool
+	var images int
+	if err := tx.QueryRowxContext(ctx, query, id).Scan(&hasDetails, &images); err != nil {
+		return false, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return hasDetails, images, nil
+}
+
+func (r *propertyRepository) UpdateStatusWithTx(ctx context.Context, id int64, status string, reason *string, tx *sqlx.Tx) (*models.Property, error) {
+	const op = "propertyRepository.UpdateStatusWithTx"
+	query := `UPDATE properties SET status = $1, status_reason = $2, version = version + 1 WHERE id = $3 RETURNING *`
+	property := &models.Property{}
+	if err := tx.QueryRowxContext(ctx, query, status, reason, id).StructScan(property); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return property, nil
+}
+
+func (r *propertyRepository) ArchiveWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Property, error) {
+	const op = "propertyRepository.ArchiveWithTx"
+	query := `UPDATE properties SET deleted_at = now(), version = version + 1 WHERE id = $1 RETURNING *`
+	property := &models.Property{}
+	if err := tx.QueryRowxContext(ctx, query, id).StructScan(property); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return property, nil
+}
+
+// Restore возвращает объявление из архива; sql.ErrNoRows - объявления нет или оно не в архиве
+func (r *propertyRepository) RestoreWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Property, error) {
+	const op = "propertyRepository.RestoreWithTx"
+	query := `UPDATE properties SET deleted_at = NULL, version = version + 1
+			  WHERE id = $1 AND deleted_at IS NOT NULL RETURNING *`
+	property := &models.Property{}
+	if err := tx.QueryRowxContext(ctx, query, id).StructScan(property); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	return property, nil
+}
 
-	return deletedID, nil
+// GetArchivedBefore возвращает id до limit объявлений, архивированных раньше before, кроме exclude
+func (r *propertyRepository) GetArchivedBefore(ctx context.Context, before time.Time, exclude []int64, limit int) ([]int64, error) {
+	const op = "propertyRepository.GetArchivedBefore"
+	query := `SELECT id FROM properties WHERE deleted_at < $1 AND id <> ALL($2::bigint[]) ORDER BY deleted_at LIMIT $3`
+	// nil уходит в запрос как NULL, и сравнение с ALL(NULL) не пропустит ни одной строки
+	if exclude == nil {
+		exclude = []int64{}
+	}
+	ids := []int64{}
+	if err := r.Db.SelectContext(ctx, &ids, query, before, exclude, limit); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
 }
 
 func (r *propertyRepository) SaveWithTx(ctx context.Context, property *models.Property, tx *sqlx.Tx) error {
-	query := `INSERT INTO properties (owner_id, title, location, price, property_type, rental_type, max_guests, created_at)
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-			  RETURNING id`
+	query := `INSERT INTO properties (owner_id, title, location, price, property_type, rental_type, max_guests, created_at,
+                        latitude, longitude)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			  RETURNING *`
 
 	// Передаём параметры в порядке их появления
 	err := tx.QueryRowxContext(ctx, query,
@@ -107,7 +228,9 @@ func (r *propertyRepository) SaveWithTx(ctx context.Context, property *models.Pr
 		property.RentalType,
 		property.MaxGuests,
 		property.CreatedAt,
-	).Scan(&property.ID)
+		property.Latitude,
+		property.Longitude,
+	).StructScan(property)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert property: %w", err)
@@ -125,28 +248,3 @@ func (r *propertyRepository) DeleteWithTx(ctx context.Context, id int64, tx *sql
 	}
 	return nil
 }
-
-func (r *propertyRepository) GetAll(ctx context.Context) ([]*models.Property, error) {
-	const op = "propertyRepository.getAll"
-	query := `SELECT * FROM properties`
-	rows, err := r.Db.QueryxContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	defer rows.Close()
-
-	properties := []*models.Property{}
-	for rows.Next() {
-		property := &models.Property{}
-		if err := rows.StructScan(property); err != nil {
-			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
-		}
-		properties = append(properties, property)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return properties, nil
-}
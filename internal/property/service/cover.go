@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/imaging"
+)
+
+// fillCoverUrls превращает ключ обложки в ссылку. Если хранилище не выдаёт прямых ссылок,
+// обложка отдаётся через GET /api/v1/images/:id.
+func (s *propertyService) fillCoverUrls(ctx context.Context, properties ...*models.Property) error {
+	for _, property := range properties {
+		if property.CoverImageKey == nil || property.CoverImageId == nil {
+			continue
+		}
+		url, err := s.images.URL(ctx, *property.CoverImageKey)
+		if err != nil {
+			return err
+		}
+		if url == "" {
+			url = fmt.Sprintf("/api/v1/images/%d?size=%s", *property.CoverImageId, imaging.SizeMedium)
+		}
+		property.CoverImageUrl = url
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSort  = "-createdAt"
+	defaultLimit = 20
+)
+
+var errRelevanceWithoutQuery = httpErrors.NewRestError(http.StatusBadRequest, "sort by relevance requires q", nil)
+
+// cursor - позиция последней записи страницы. Sort сохраняется, чтобы курсор нельзя было
+// применить к выборке с другой сортировкой.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    int64  `json:"id"`
+}
+
+func newListQuery(req *request.PropertyListRequest) (*models.PropertyListQuery, error) {
+	near, bbox, err := parseGeoParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sort := req.Sort
+	if sort == "" {
+		sort = defaultSort
+		if near != nil {
+			sort = "distance"
+		}
+	}
+	if sort == "distance" && near == nil {
+		return nil, httpErrors.NewRestError(http.StatusBadRequest, "sort by distance requires near", nil)
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	query := &models.PropertyListQuery{
+		Sort: sort,
+		Filter: models.PropertyFilter{
+			PropertyType: req.PropertyType,
+			RentalType:   req.RentalType,
+			MinPrice:     req.MinPrice,
+			MaxPrice:     req.MaxPrice,
+			MinGuests:    req.MinGuests,
+			MinRooms:     req.MinRooms,
+			MaxRooms:     req.MaxRooms,
+			MinArea:      req.MinArea,
+			MaxArea:      req.MaxArea,
+			Near:         near,
+			RadiusMeters: req.Radius,
+			BBox:         bbox,
+		},
+		SortField: strings.TrimPrefix(sort, "-"),
+		Desc:      strings.HasPrefix(sort, "-"),
+		Limit:     limit,
+	}
+	if sort == "relevance" {
+		// Сначала самые релевантные
+		query.Desc = true
+	}
+
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil || c.Sort != sort {
+			return nil, httpErrors.NewRestError(http.StatusBadRequest, "invalid cursor", nil)
+		}
+		query.AfterValue = &c.Value
+		query.AfterId = c.Id
+	}
+	return query, nil
+}
+
+// encodeCursor кодирует позицию последней записи страницы; rank используется только при сортировке по релевантности
+func encodeCursor(query *models.PropertyListQuery, last *models.Property, rank float64) string {
+	c := cursor{Sort: query.Sort, Id: last.ID}
+	switch query.SortField {
+	case "price":
+		c.Value = strconv.Itoa(last.Price)
+	case "rating":
+		c.Value = strconv.FormatFloat(last.Rating, 'f', -1, 64)
+	case "relevance":
+		c.Value = strconv.FormatFloat(rank, 'f', -1, 32)
+	case "distance":
+		if last.Distance != nil {
+			c.Value = strconv.FormatFloat(*last.Distance, 'f', -1, 64)
+		}
+	case "createdAt":
+		c.Value = last.CreatedAt
+		if formatted, err := utils.ParseDate(&last.CreatedAt); err == nil {
+			c.Value = formatted
+		}
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"property-managment-service/internal/geocoding"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/httpErrors"
+	"strconv"
+	"strings"
+)
+
+// parseGeoParams разбирает параметры near=lat,lng и bbox=minLat,minLng,maxLat,maxLng
+func parseGeoParams(req *request.PropertyListRequest) (*models.Coordinates, *models.BoundingBox, error) {
+	var near *models.Coordinates
+	if req.Near != "" {
+		values, err := parseFloats(req.Near, 2)
+		if err != nil || !validLatitude(values[0]) || !validLongitude(values[1]) {
+			return nil, nil, httpErrors.NewRestError(http.StatusBadRequest, "near must be lat,lng", nil)
+		}
+		near = &models.Coordinates{Latitude: values[0], Longitude: values[1]}
+	} else if req.Radius > 0 {
+		return nil, nil, httpErrors.NewRestError(http.StatusBadRequest, "radius requires near", nil)
+	}
+
+	var bbox *models.BoundingBox
+	if req.BBox != "" {
+		values, err := parseFloats(req.BBox, 4)
+		if err != nil || !validLatitude(values[0]) || !validLongitude(values[1]) ||
+			!validLatitude(values[2]) || !validLongitude(values[3]) ||
+			values[0] > values[2] || values[1] > values[3] {
+			return nil, nil, httpErrors.NewRestError(http.StatusBadRequest, "bbox must be minLat,minLng,maxLat,maxLng", nil)
+		}
+		bbox = &models.BoundingBox{
+			MinLatitude:  values[0],
+			MinLongitude: values[1],
+			MaxLatitude:  values[2],
+			MaxLongitude: values[3],
+		}
+	}
+	return near, bbox, nil
+}
+
+func parseFloats(value string, count int) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != count {
+		return nil, errors.New("unexpected number of values")
+	}
+	values := make([]float64, count)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func validLatitude(v float64) bool {
+	return v >= -90 && v <= 90
+}
+
+func validLongitude(v float64) bool {
+	return v >= -180 && v <= 180
+}
+
+// resolveCoordinates заполняет координаты объекта по адресу, если клиент их не передал.
+// Если адрес не найден, объект сохраняется без координат и не попадает в поиск по карте.
+func (s *propertyService) resolveCoordinates(ctx context.Context, property *models.Property) error {
+	if property.Latitude != nil && property.Longitude != nil {
+		return nil
+	}
+
+	coordinates, err := s.geocode(ctx, property.Location)
+	if err != nil || coordinates == nil {
+		return err
+	}
+
+	property.Latitude = &coordinates.Latitude
+	property.Longitude = &coordinates.Longitude
+	return nil
+}
+
+// geocode возвращает nil без ошибки, если адрес не найден
+func (s *propertyService) geocode(ctx context.Context, location string) (*models.Coordinates, error) {
+	coordinates, err := s.geocoder.Geocode(ctx, location)
+	if err != nil {
+		if errors.Is(err, geocoding.ErrNotFound) {
+			s.log.Warn("resolveCoordinates: location not found", "location", location)
+			return nil, nil
+		}
+		return nil, err
+	}
+	return coordinates, nil
+}
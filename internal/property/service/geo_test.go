@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"property-managment-service/internal/geocoding"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestParseGeoParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      request.PropertyListRequest
+		wantNear *models.Coordinates
+		wantBBox *models.BoundingBox
+		wantErr  bool
+	}{
+		{name: "no geo params"},
+		{
+			name:     "near",
+			req:      request.PropertyListRequest{Near: "55.75,37.61"},
+			wantNear: &models.Coordinates{Latitude: 55.75, Longitude: 37.61},
+		},
+		{
+			name:     "near with radius and spaces",
+			req:      request.PropertyListRequest{Near: " -33.86 , 151.21 ", Radius: 5000},
+			wantNear: &models.Coordinates{Latitude: -33.86, Longitude: 151.21},
+		},
+		{
+			name:     "near on the limits",
+			req:      request.PropertyListRequest{Near: "90,-180"},
+			wantNear: &models.Coordinates{Latitude: 90, Longitude: -180},
+		},
+		{name: "near latitude out of range", req: request.PropertyListRequest{Near: "90.1,37"}, wantErr: true},
+		{name: "near longitude out of range", req: request.PropertyListRequest{Near: "55,180.5"}, wantErr: true},
+		{name: "near is not a number", req: request.PropertyListRequest{Near: "north,east"}, wantErr: true},
+		{name: "near with one value", req: request.PropertyListRequest{Near: "55.75"}, wantErr: true},
+		{name: "near with three values", req: request.PropertyListRequest{Near: "55,37,1"}, wantErr: true},
+		{name: "radius without near", req: request.PropertyListRequest{Radius: 1000}, wantErr: true},
+		{
+			name:     "bbox",
+			req:      request.PropertyListRequest{BBox: "55.5,37.3,56,37.9"},
+			wantBBox: &models.BoundingBox{MinLatitude: 55.5, MinLongitude: 37.3, MaxLatitude: 56, MaxLongitude: 37.9},
+		},
+		{
+			name:     "degenerate bbox",
+			req:      request.PropertyListRequest{BBox: "55,37,55,37"},
+			wantBBox: &models.BoundingBox{MinLatitude: 55, MinLongitude: 37, MaxLatitude: 55, MaxLongitude: 37},
+		},
+		{
+			name:     "near and bbox together",
+			req:      request.PropertyListRequest{Near: "55.75,37.61", BBox: "55.5,37.3,56,37.9"},
+			wantNear: &models.Coordinates{Latitude: 55.75, Longitude: 37.61},
+			wantBBox: &models.BoundingBox{MinLatitude: 55.5, MinLongitude: 37.3, MaxLatitude: 56, MaxLongitude: 37.9},
+		},
+		{name: "bbox min latitude above max", req: request.PropertyListRequest{BBox: "56,37.3,55.5,37.9"}, wantErr: true},
+		{name: "bbox min longitude above max", req: request.PropertyListRequest{BBox: "55.5,37.9,56,37.3"}, wantErr: true},
+		{name: "bbox out of range", req: request.PropertyListRequest{BBox: "-91,0,10,10"}, wantErr: true},
+		{name: "bbox with three values", req: request.PropertyListRequest{BBox: "55,37,56"}, wantErr: true},
+		{name: "bbox is not a number", req: request.PropertyListRequest{BBox: "a,b,c,d"}, wantErr: true},
+		{name: "invalid bbox with valid near", req: request.PropertyListRequest{Near: "55,37", BBox: "1,2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			near, bbox, err := parseGeoParams(&tt.req)
+			if tt.wantErr {
+				var restErr httpErrors.RestErr
+				if !errors.As(err, &restErr) || restErr.Status() != http.StatusBadRequest {
+					t.Errorf("err = %v, want 400", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(near, tt.wantNear) {
+				t.Errorf("near = %+v, want %+v", near, tt.wantNear)
+			}
+			if !reflect.DeepEqual(bbox, tt.wantBBox) {
+				t.Errorf("bbox = %+v, want %+v", bbox, tt.wantBBox)
+			}
+		})
+	}
+}
+
+// Радиус проверяется валидатором запроса ещё до parseGeoParams
+func TestPropertyListRequestRadius(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{query: "near=55,37&radius=0"},
+		{query: "near=55,37&radius=1500.5"},
+		{query: "near=55,37&radius=100000"},
+		{query: "near=55,37&radius=100001", wantErr: true},
+		{query: "near=55,37&radius=-1", wantErr: true},
+		{query: "near=55,37&radius=far", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/properties?"+tt.query, nil), httptest.NewRecorder())
+			req := &request.PropertyListRequest{}
+			err := utils.ReadRequest(c, req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadRequest error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if _, _, err := parseGeoParams(req); err != nil {
+					t.Errorf("parseGeoParams: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// failingGeocoder имитирует недоступный сервис геокодирования
+type failingGeocoder struct{}
+
+func (failingGeocoder) Geocode(context.Context, string) (*models.Coordinates, error) {
+	return nil, errors.New("geocoder is down")
+}
+
+func TestResolveCoordinates(t *testing.T) {
+	fixtures, err := geocoding.NewFixtureGeocoder(filepath.Join("..", "..", "..", "config", "geocoding.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	latitude, longitude := 1.5, 2.5
+
+	tests := []struct {
+		name     string
+		geocoder geocoding.Geocoder
+		property models.Property
+		want     *models.Coordinates
+		wantErr  bool
+	}{
+		{
+			name:     "known address",
+			geocoder: fixtures,
+			property: models.Property{Location: "москва, арбат, 10"},
+			want:     &models.Coordinates{Latitude: 55.751185, Longitude: 37.597309},
+		},
+		{
+			name:     "unknown address is saved without coordinates",
+			geocoder: fixtures,
+			property: models.Property{Location: "Атлантида, улица Главная, 1"},
+		},
+		{
+			name:     "client coordinates are kept",
+			geocoder: fixtures,
+			property: models.Property{Location: "Москва, Арбат, 10", Latitude: &latitude, Longitude: &longitude},
+			want:     &models.Coordinates{Latitude: latitude, Longitude: longitude},
+		},
+		{
+			name:     "client coordinates skip the geocoder",
+			geocoder: failingGeocoder{},
+			property: models.Property{Location: "Москва, Арбат, 10", Latitude: &latitude, Longitude: &longitude},
+			want:     &models.Coordinates{Latitude: latitude, Longitude: longitude},
+		},
+		{
+			name:     "geocoder error",
+			geocoder: failingGeocoder{},
+			property: models.Property{Location: "Москва, Арбат, 10"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &propertyService{geocoder: tt.geocoder, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+			property := tt.property
+
+			err := s.resolveCoordinates(context.Background(), &property)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got *models.Coordinates
+			if property.Latitude != nil && property.Longitude != nil {
+				got = &models.Coordinates{Latitude: *property.Latitude, Longitude: *property.Longitude}
+			} else if property.Latitude != nil || property.Longitude != nil {
+				t.Fatalf("only one coordinate is set: %v, %v", property.Latitude, property.Longitude)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("coordinates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,38 +2,82 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"log/slog"
+	audit "property-managment-service/internal/audit/service"
+	"property-managment-service/internal/config"
+	"property-managment-service/internal/geocoding"
+	"property-managment-service/internal/image/storage"
 	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
 	"property-managment-service/internal/property/delivery/http"
+	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/httpErrors"
 	"property-managment-service/pkg/utils"
 	"time"
 )
 
 type PropertyRepository interface {
-	Create(ctx context.Context, property *models.Property) (*models.Property, error)
 	GetById(ctx context.Context, id int64) (*models.Property, error)
-	GetByOwnerId(ctx context.Context, id int64) ([]*models.Property, error)
-	Update(ctx context.Context, property *models.Property) (*models.Property, error)
-	Delete(ctx context.Context, id int64) (int64, error)
+	GetByOwnerId(ctx context.Context, id int64, includeUnpublished bool) ([]*models.Property, error)
+	GetByStatus(ctx context.Context, status string) ([]*models.Property, error)
+	UpdateWithTx(ctx context.Context, property *models.Property, ifMatch []int64, tx *sqlx.Tx) (*models.Property, error)
+	PatchWithTx(ctx context.Context, id int64, columns map[string]interface{}, ifMatch []int64, tx *sqlx.Tx) (*models.Property, error)
+	GetArchivedByOwnerId(ctx context.Context, id int64) ([]*models.Property, error)
+	GetByIdForUpdateWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Property, error)
+	GetCompletenessWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (bool, int, error)
+	UpdateStatusWithTx(ctx context.Context, id int64, status string, reason *string, tx *sqlx.Tx) (*models.Property, error)
+	HasUpcomingBookingsWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (bool, error)
+	ArchiveWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Property, error)
+	RestoreWithTx(ctx context.Context, id int64, tx *sqlx.Tx) (*models.Property, error)
+	GetArchivedBefore(ctx context.Context, before time.Time, exclude []int64, limit int) ([]int64, error)
 	SaveWithTx(ctx context.Context, property *models.Property, tx *sqlx.Tx) error
 	DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error
-	GetAll(ctx context.Context) ([]*models.Property, error)
+	List(ctx context.Context, query *models.PropertyListQuery) ([]*models.Property, error)
+	Search(ctx context.Context, query *models.PropertyListQuery, text string) ([]*models.PropertySearchResult, error)
 }
 
 type propertyService struct {
-	log          *slog.Logger
-	propertyRepo PropertyRepository
+	log                *slog.Logger
+	propertyRepo       PropertyRepository
+	geocoder           geocoding.Geocoder
+	images             storage.ImageStorage
+	transactionManager db.TransactionManager
+	cfg                config.PropertiesConfig
+	audit              *audit.AuditService
 }
 
-func NewPropertyService(propertyRepo PropertyRepository, log *slog.Logger) http.PropertyService {
-	return &propertyService{log, propertyRepo}
+func NewPropertyService(
+	propertyRepo PropertyRepository,
+	geocoder geocoding.Geocoder,
+	images storage.ImageStorage,
+	transactionManager db.TransactionManager,
+	cfg config.PropertiesConfig,
+	audit *audit.AuditService,
+	log *slog.Logger,
+) http.PropertyService {
+	return &propertyService{log, propertyRepo, geocoder, images, transactionManager, cfg, audit}
 }
 
 func (s *propertyService) Create(ctx context.Context, property *models.Property) (*models.Property, error) {
-	property.CreatedAt = time.Now().Format("2006-01-2")
-	property, err := s.propertyRepo.Create(ctx, property)
+	tx, err := s.transactionManager.BeginTransaction(ctx)
 	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := s.SaveWithTx(ctx, property, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	formattedDate, err := utils.ParseDate(&property.CreatedAt)
@@ -52,25 +96,179 @@ func (s *propertyService) GetById(ctx context.Context, id int64) (*models.Proper
 	return property, nil
 }
 
-func (s *propertyService) GetByOwnerId(ctx context.Context, id int64) ([]*models.Property, error) {
-	properties, err := s.propertyRepo.GetByOwnerId(ctx, id)
+func (s *propertyService) GetByOwnerId(ctx context.Context, id int64, includeUnpublished bool) ([]*models.Property, error) {
+	properties, err := s.propertyRepo.GetByOwnerId(ctx, id, includeUnpublished)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillCoverUrls(ctx, properties...); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+func (s *propertyService) GetArchivedByOwnerId(ctx context.Context, id int64) ([]*models.Property, error) {
+	properties, err := s.propertyRepo.GetArchivedByOwnerId(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.fillCoverUrls(ctx, properties...); err != nil {
+		return nil, err
+	}
 	return properties, nil
 }
 
+// Delete переносит объявление в архив. Повторное удаление ничего не меняет.
+// Пока есть подтверждённые брони, которые ещё не закончились, объявление не архивируется.
 func (s *propertyService) Delete(ctx context.Context, id int64) (int64, error) {
-	deleteId, err := s.propertyRepo.Delete(ctx, id)
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	// Блокировка строки не даёт подтвердить бронь, пока идёт проверка
+	property, err := s.propertyRepo.GetByIdForUpdateWithTx(ctx, id, tx)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if property.DeletedAt != nil {
+		tx.Rollback()
+		return id, nil
+	}
+
+	upcoming, err := s.propertyRepo.HasUpcomingBookingsWithTx(ctx, id, tx)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if upcoming {
+		tx.Rollback()
+		return 0, httpErrors.NewConflictError("property has upcoming confirmed bookings")
+	}
+
+	archived, err := s.propertyRepo.ArchiveWithTx(ctx, id, tx)
 	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionArchive, models.AuditEntityProperty, id, property, archived); err != nil {
+		tx.Rollback()
 		return 0, err
 	}
-	return deleteId, nil
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *propertyService) Restore(ctx context.Context, id int64) (*models.Property, error) {
+	return s.changeWithTx(ctx, id, models.AuditActionRestore, func(before *models.Property, tx *sqlx.Tx) (*models.Property, error) {
+		if before.DeletedAt == nil {
+			return nil, httpErrors.NewConflictError("property is not archived")
+		}
+		return s.propertyRepo.RestoreWithTx(ctx, id, tx)
+	})
+}
+
+func (s *propertyService) GetArchivedBefore(ctx context.Context, before time.Time, exclude []int64, limit int) ([]int64, error) {
+	return s.propertyRepo.GetArchivedBefore(ctx, before, exclude, limit)
+}
+
+func (s *propertyService) Update(ctx context.Context, property *models.Property, ifMatch []int64) (*models.Property, error) {
+	before, err := s.propertyRepo.GetById(ctx, property.ID)
+	if err != nil {
+		return nil, err
+	}
+	if before.DeletedAt != nil {
+		return nil, httpErrors.NewConflictError("property is archived")
+	}
+	if err := s.resolveCoordinates(ctx, property); err != nil {
+		return nil, err
+	}
+	return s.changeWithTx(ctx, property.ID, models.AuditActionUpdate, func(_ *models.Property, tx *sqlx.Tx) (*models.Property, error) {
+		return s.propertyRepo.UpdateWithTx(ctx, property, ifMatch, tx)
+	})
+}
+
+// Patch применяет JSON Merge Patch. При смене адреса без новых координат они определяются заново
+func (s *propertyService) Patch(ctx context.Context, id int64, patch *utils.MergePatch, ifMatch []int64) (*models.Property, error) {
+	if patch.Has("latitude") != patch.Has("longitude") {
+		return nil, httpErrors.NewBadRequestError("latitude and longitude must be changed together")
+	}
+	before, err := s.propertyRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if before.DeletedAt != nil {
+		return nil, httpErrors.NewConflictError("property is archived")
+	}
+	if patch.Empty() {
+		if err := utils.CheckVersion(ifMatch, before.Version); err != nil {
+			return nil, err
+		}
+		return before, nil
+	}
+
+	if patch.Has("location") && !patch.Has("latitude") {
+		coordinates, err := s.geocode(ctx, patch.Columns["location"].(string))
+		if err != nil {
+			return nil, err
+		}
+		// Старые координаты относятся к прежнему адресу; ненайденный адрес оставляет объект без координат
+		patch.Columns["latitude"], patch.Columns["longitude"] = nil, nil
+		if coordinates != nil {
+			patch.Columns["latitude"], patch.Columns["longitude"] = coordinates.Latitude, coordinates.Longitude
+		}
+	}
+
+	return s.changeWithTx(ctx, id, models.AuditActionUpdate, func(_ *models.Property, tx *sqlx.Tx) (*models.Property, error) {
+		return s.propertyRepo.PatchWithTx(ctx, id, patch.Columns, ifMatch, tx)
+	})
 }
 
-func (s *propertyService) Update(ctx context.Context, property *models.Property) (*models.Property, error) {
-	property, err := s.propertyRepo.Update(ctx, property)
+// changeWithTx выполняет change в транзакции и записывает изменение в журнал той же транзакцией.
+// Состояние до изменения читается под блокировкой строки, чтобы в журнал не попали чужие правки.
+func (s *propertyService) changeWithTx(ctx context.Context, id int64, action string, change func(before *models.Property, tx *sqlx.Tx) (*models.Property, error)) (*models.Property, error) {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	before, err := s.propertyRepo.GetByIdForUpdateWithTx(ctx, id, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	property, err := change(before, tx)
 	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.audit.RecordWithTx(ctx, tx, action, models.AuditEntityProperty, id, before, property); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	return property, nil
@@ -78,21 +276,82 @@ func (s *propertyService) Update(ctx context.Context, property *models.Property)
 
 func (s *propertyService) SaveWithTx(ctx context.Context, property *models.Property, tx *sqlx.Tx) error {
 	property.CreatedAt = time.Now().Format("2006-01-2")
-	return s.propertyRepo.SaveWithTx(ctx, property, tx)
+	if err := s.resolveCoordinates(ctx, property); err != nil {
+		return err
+	}
+	if err := s.propertyRepo.SaveWithTx(ctx, property, tx); err != nil {
+		return err
+	}
+	return s.audit.RecordWithTx(ctx, tx, models.AuditActionCreate, models.AuditEntityProperty, property.ID, nil, property)
 }
 
 func (s *propertyService) DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error {
-	err := s.propertyRepo.DeleteWithTx(ctx, id, tx)
+	property, err := s.propertyRepo.GetByIdForUpdateWithTx(ctx, id, tx)
 	if err != nil {
 		return err
 	}
-	return nil
+	if err := s.propertyRepo.DeleteWithTx(ctx, id, tx); err != nil {
+		return err
+	}
+	return s.audit.RecordWithTx(ctx, tx, models.AuditActionDelete, models.AuditEntityProperty, id, property, nil)
 }
 
-func (s *propertyService) GetAll(ctx context.Context) ([]*models.Property, error) {
-	properties, err := s.propertyRepo.GetAll(ctx)
+func (s *propertyService) List(ctx context.Context, req *request.PropertyListRequest) (*models.PropertyPage, error) {
+	if req.Sort == "relevance" {
+		return nil, errRelevanceWithoutQuery
+	}
+	query, err := newListQuery(req)
 	if err != nil {
 		return nil, err
 	}
-	return properties, nil
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	limit := query.Limit
+	query.Limit++
+	properties, err := s.propertyRepo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fillCoverUrls(ctx, properties...); err != nil {
+		return nil, err
+	}
+
+	page := &models.PropertyPage{Items: properties}
+	if len(properties) > limit {
+		page.Items = properties[:limit]
+		page.NextCursor = encodeCursor(query, page.Items[limit-1], 0)
+	}
+	return page, nil
+}
+
+func (s *propertyService) Search(ctx context.Context, req *request.PropertySearchRequest) (*models.PropertySearchPage, error) {
+	if req.Sort == "" {
+		req.Sort = "relevance"
+	}
+	query, err := newListQuery(&req.PropertyListRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	query.Limit++
+	results, err := s.propertyRepo.Search(ctx, query, req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if err := s.fillCoverUrls(ctx, &result.Property); err != nil {
+			return nil, err
+		}
+	}
+
+	page := &models.PropertySearchPage{Items: results}
+	if len(results) > limit {
+		page.Items = results[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeCursor(query, &last.Property, last.Rank)
+	}
+	return page, nil
 }
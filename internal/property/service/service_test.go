@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	audit "property-managment-service/internal/audit/service"
+	"property-managment-service/internal/geocoding"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/db/dbtest"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// fakePropertyRepo хранит одно объявление и отмечает попытки его изменить
+type fakePropertyRepo struct {
+	PropertyRepository
+	property *models.Property
+	updated  bool
+}
+
+func (r *fakePropertyRepo) GetById(context.Context, int64) (*models.Property, error) {
+	property := *r.property
+	return &property, nil
+}
+
+func (r *fakePropertyRepo) GetByIdForUpdateWithTx(ctx context.Context, id int64, _ *sqlx.Tx) (*models.Property, error) {
+	return r.GetById(ctx, id)
+}
+
+func (r *fakePropertyRepo) SaveWithTx(_ context.Context, property *models.Property, _ *sqlx.Tx) error {
+	property.ID = 1
+	r.updated = true
+	return nil
+}
+
+// Как и настоящий репозиторий, архивное объявление не меняется
+func (r *fakePropertyRepo) UpdateWithTx(_ context.Context, property *models.Property, _ []int64, _ *sqlx.Tx) (*models.Property, error) {
+	if r.property.DeletedAt != nil {
+		return nil, httpErrors.NewConflictError("property is archived")
+	}
+	r.updated = true
+	return property, nil
+}
+
+func (r *fakePropertyRepo) PatchWithTx(_ context.Context, _ int64, columns map[string]interface{}, _ []int64, _ *sqlx.Tx) (*models.Property, error) {
+	if r.property.DeletedAt != nil {
+		return nil, httpErrors.NewConflictError("property is archived")
+	}
+	r.updated = true
+	property := *r.property
+	property.Title = columns["title"].(string)
+	return &property, nil
+}
+
+func (r *fakePropertyRepo) RestoreWithTx(context.Context, int64, *sqlx.Tx) (*models.Property, error) {
+	r.updated = true
+	property := *r.property
+	property.DeletedAt = nil
+	return &property, nil
+}
+
+// fakeAuditRepo считает записи журнала; err имитирует сбой вставки
+type fakeAuditRepo struct {
+	audit.AuditRepository
+	entries []*models.AuditEntry
+	err     error
+}
+
+func (r *fakeAuditRepo) CreateWithTx(_ context.Context, entry *models.AuditEntry, _ *sqlx.Tx) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func newTestPropertyService(repo PropertyRepository, auditRepo audit.AuditRepository) (*propertyService, *dbtest.TransactionManager) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tm := dbtest.NewTransactionManager()
+	return &propertyService{
+		log:                log,
+		propertyRepo:       repo,
+		geocoder:           geocoding.NewNoopGeocoder(),
+		transactionManager: tm,
+		audit:              audit.NewAuditService(auditRepo, log),
+	}, tm
+}
+
+// titlePatch разбирает merge patch так же, как обработчик PATCH
+func titlePatch(t *testing.T) *utils.MergePatch {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, "/properties/1", strings.NewReader(`{"title": "New"}`))
+	req.Header.Set(echo.HeaderContentType, utils.MIMEMergePatch)
+	patch, err := utils.ReadMergePatch(echo.New().NewContext(req, httptest.NewRecorder()), &request.PatchPropertyRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patch
+}
+
+func TestArchivedPropertyIsReadOnly(t *testing.T) {
+	archivedAt := time.Now()
+	repo := &fakePropertyRepo{property: &models.Property{ID: 1, Title: "Old", DeletedAt: &archivedAt}}
+	s, _ := newTestPropertyService(repo, &fakeAuditRepo{})
+	ctx := context.Background()
+
+	tests := map[string]func() error{
+		"update": func() error {
+			_, err := s.Update(ctx, &models.Property{ID: 1, Title: "New"}, nil)
+			return err
+		},
+		"patch": func() error {
+			_, err := s.Patch(ctx, 1, &utils.MergePatch{Columns: map[string]interface{}{"title": "New"}}, nil)
+			return err
+		},
+	}
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := fn()
+			var restErr httpErrors.RestErr
+			if !errors.As(err, &restErr) || restErr.Status() != http.StatusConflict {
+				t.Errorf("err = %v, want 409", err)
+			}
+			if repo.updated {
+				t.Error("archived property was modified")
+			}
+		})
+	}
+}
+
+// Изменение и запись журнала коммитятся вместе: без записи в журнале изменение откатывается
+func TestChangesAreAuditedInTransaction(t *testing.T) {
+	ctx := context.Background()
+	archivedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		property models.Property
+		change   func(s *propertyService) error
+	}{
+		{
+			name: "create",
+			change: func(s *propertyService) error {
+				_, err := s.Create(ctx, &models.Property{Title: "New", Location: "Москва"})
+				return err
+			},
+		},
+		{
+			name:     "update",
+			property: models.Property{ID: 1, Title: "Old"},
+			change: func(s *propertyService) error {
+				_, err := s.Update(ctx, &models.Property{ID: 1, Title: "New"}, nil)
+				return err
+			},
+		},
+		{
+			name:     "patch",
+			property: models.Property{ID: 1, Title: "Old"},
+			change: func(s *propertyService) error {
+				_, err := s.Patch(ctx, 1, titlePatch(t), nil)
+				return err
+			},
+		},
+		{
+			name:     "restore",
+			property: models.Property{ID: 1, Title: "Old", DeletedAt: &archivedAt},
+			change: func(s *propertyService) error {
+				_, err := s.Restore(ctx, 1)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			property := tt.property
+			auditRepo := &fakeAuditRepo{}
+			s, tm := newTestPropertyService(&fakePropertyRepo{property: &property}, auditRepo)
+			if err := tt.change(s); err != nil {
+				t.Fatal(err)
+			}
+			if len(auditRepo.entries) != 1 || tm.Commits() != 1 {
+				t.Errorf("audit entries = %d, commits = %d, want 1 and 1", len(auditRepo.entries), tm.Commits())
+			}
+
+			property = tt.property
+			auditRepo = &fakeAuditRepo{err: errors.New("audit log is unavailable")}
+			s, tm = newTestPropertyService(&fakePropertyRepo{property: &property}, auditRepo)
+			if err := tt.change(s); err == nil {
+				t.Fatal("change succeeded without an audit entry")
+			}
+			if tm.Commits() != 0 || tm.Rollbacks() != 1 {
+				t.Errorf("commits = %d, rollbacks = %d, want 0 and 1", tm.Commits(), tm.Rollbacks())
+			}
+		})
+	}
+}
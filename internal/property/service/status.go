@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"property-managment-service/internal/models"
+	"property-managment-service/pkg/httpErrors"
+	"slices"
+)
+
+// statusSources - из каких статусов объявление можно перевести в целевой.
+// Приостановленное владелец исправляет и отправляет на проверку заново, администратор может сразу вернуть его в публикацию.
+var statusSources = map[string][]string{
+	models.PropertyStatusPendingReview: {models.PropertyStatusDraft, models.PropertyStatusSuspended},
+	models.PropertyStatusPublished:     {models.PropertyStatusPendingReview, models.PropertyStatusSuspended},
+	models.PropertyStatusSuspended:     {models.PropertyStatusPendingReview, models.PropertyStatusPublished},
+}
+
+// statusCheck - дополнительная проверка объявления перед сменой статуса
+type statusCheck func(ctx context.Context, property *models.Property, tx *sqlx.Tx) error
+
+// Submit отправляет черновик на проверку, если объявление заполнено полностью
+func (s *propertyService) Submit(ctx context.Context, id int64) (*models.Property, error) {
+	return s.changeStatus(ctx, id, models.PropertyStatusPendingReview, nil, s.checkComplete)
+}
+
+func (s *propertyService) Approve(ctx context.Context, id int64) (*models.Property, error) {
+	return s.changeStatus(ctx, id, models.PropertyStatusPublished, nil, nil)
+}
+
+func (s *propertyService) Suspend(ctx context.Context, id int64, reason string) (*models.Property, error) {
+	return s.changeStatus(ctx, id, models.PropertyStatusSuspended, &reason, nil)
+}
+
+func (s *propertyService) GetByStatus(ctx context.Context, status string) ([]*models.Property, error) {
+	properties, err := s.propertyRepo.GetByStatus(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillCoverUrls(ctx, properties...); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+func (s *propertyService) changeStatus(ctx context.Context, id int64, to string, reason *string, check statusCheck) (*models.Property, error) {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	property, err := s.propertyRepo.GetByIdForUpdateWithTx(ctx, id, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if property.DeletedAt != nil {
+		tx.Rollback()
+		return nil, httpErrors.NewConflictError("property is archived")
+	}
+	if !slices.Contains(statusSources[to], property.Status) {
+		tx.Rollback()
+		return nil, httpErrors.NewConflictError(fmt.Sprintf("cannot change status from %s to %s", property.Status, to))
+	}
+
+	if check != nil {
+		if err := check(ctx, property, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	before := property
+	property, err = s.propertyRepo.UpdateStatusWithTx(ctx, id, to, reason, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.audit.RecordWithTx(ctx, tx, models.AuditActionUpdate, models.AuditEntityProperty, id, before, property); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("changeStatus", "property id", id, "from", before.Status, "to", to)
+	return property, nil
+}
+
+// checkComplete проверяет, что у объявления есть цена, детали и не меньше MinImages изображений
+func (s *propertyService) checkComplete(ctx context.Context, property *models.Property, tx *sqlx.Tx) error {
+	hasDetails, images, err := s.propertyRepo.GetCompletenessWithTx(ctx, property.ID, tx)
+	if err != nil {
+		return err
+	}
+
+	var fields []httpErrors.FieldError
+	if property.Price <= 0 {
+		fields = append(fields, httpErrors.FieldError{Field: "price", Message: "must be greater than 0"})
+	}
+	if !hasDetails {
+		fields = append(fields, httpErrors.FieldError{Field: "propertyDetails", Message: "is required"})
+	}
+	if images < s.cfg.MinImages {
+		fields = append(fields, httpErrors.FieldError{Field: "images",
+			Message: fmt.Sprintf("must contain at least %d items", s.cfg.MinImages)})
+	}
+	if len(fields) > 0 {
+		return httpErrors.NewValidationError(fields, nil)
+	}
+	return nil
+}
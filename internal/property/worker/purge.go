@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"property-managment-service/internal/property/delivery/http"
+	"property-managment-service/lib/sl"
+	"time"
+)
+
+// PurgeWorker периодически удаляет объявления, которые пролежали в архиве дольше retention
+type PurgeWorker struct {
+	formService http.PropertyFormService
+	interval    time.Duration
+	retention   time.Duration
+	log         *slog.Logger
+}
+
+func NewPurgeWorker(formService http.PropertyFormService, interval time.Duration, retention time.Duration, log *slog.Logger) *PurgeWorker {
+	return &PurgeWorker{formService: formService, interval: interval, retention: retention, log: log}
+}
+
+func (w *PurgeWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *PurgeWorker) purge(ctx context.Context) {
+	purged, err := w.formService.PurgeArchived(ctx, time.Now().Add(-w.retention))
+	if err != nil {
+		w.log.Error("PurgeWorker", sl.Err(err))
+	}
+	if purged > 0 {
+		w.log.Info("PurgeWorker", "purged properties", purged)
+	}
+}
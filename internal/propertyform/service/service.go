@@ -2,19 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	http2 "property-managment-service/internal/image/delivery/http"
+	"property-managment-service/internal/models"
 	"property-managment-service/internal/models/request"
 	http3 "property-managment-service/internal/propdetails/delivery/http"
 	"property-managment-service/internal/property/delivery/http"
+	"property-managment-service/lib/sl"
 	"property-managment-service/pkg/db"
+	"time"
 )
 
+const purgeBatchSize = 100
+
 type propertyFormService struct {
 	transactionManager     db.TransactionManager
 	propertyService        http.PropertyService
 	imageService           http2.ImageService
 	propertyDetailsService http3.PropertyDetailsService
+	log                    *slog.Logger
 }
 
 func NewPropertyFormService(
@@ -22,12 +30,14 @@ func NewPropertyFormService(
 	propertyService http.PropertyService,
 	imageService http2.ImageService,
 	propertyDetailsService http3.PropertyDetailsService,
+	log *slog.Logger,
 ) http.PropertyFormService {
 	return &propertyFormService{
 		transactionManager:     transactionManager,
 		propertyService:        propertyService,
 		imageService:           imageService,
 		propertyDetailsService: propertyDetailsService,
+		log:                    log,
 	}
 }
 
@@ -69,18 +79,61 @@ func (s *propertyFormService) SavePropertyForm(ctx context.Context, form *reques
 		return fmt.Errorf("failed to save property details: %w", err)
 	}
 
+	var images []models.Image
 	if len(form.Images) > 0 {
-		err = s.imageService.UploadImagesFromBase64(ctx, form.Images, form.Property.ID, tx)
+		images, err = s.imageService.UploadImagesFromBase64(ctx, form.Images, form.Property.ID, tx)
 		if err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to upload images: %w", err)
 		}
 	}
 
-	return tx.Commit()
+	// Записи об изображениях не сохранились, поэтому их файлы удаляем сразу, не дожидаясь GC
+	if err := tx.Commit(); err != nil {
+		s.imageService.RemoveImageFiles(ctx, images)
+		return err
+	}
+	return nil
 }
 
+// DeletePropertyForm архивирует объявление; изображения и детали удаляются вместе с ним при очистке архива
 func (s *propertyFormService) DeletePropertyForm(ctx context.Context, propertyID int64) error {
+	_, err := s.propertyService.Delete(ctx, propertyID)
+	return err
+}
+
+// PurgeArchived удаляет объявления, архивированные раньше before, вместе с изображениями и деталями.
+// Объявление, которое не удалось удалить, пропускается до следующего запуска и не мешает остальным;
+// все такие ошибки возвращаются вместе в конце.
+func (s *propertyFormService) PurgeArchived(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	failed := []int64{}
+	var errs []error
+	for {
+		// Без исключения упавших id партия из одних ошибок выбиралась бы снова и снова
+		ids, err := s.propertyService.GetArchivedBefore(ctx, before, failed, purgeBatchSize)
+		if err != nil {
+			return purged, errors.Join(append(errs, err)...)
+		}
+		for _, id := range ids {
+			if err := s.purge(ctx, id); err != nil {
+				s.log.Error("failed to purge property", slog.Int64("property id", id), sl.Err(err))
+				failed = append(failed, id)
+				errs = append(errs, fmt.Errorf("failed to purge property %d: %w", id, err))
+				continue
+			}
+			purged++
+		}
+		if len(ids) < purgeBatchSize {
+			if len(errs) > 0 {
+				return purged, fmt.Errorf("failed to purge %d properties: %w", len(errs), errors.Join(errs...))
+			}
+			return purged, nil
+		}
+	}
+}
+
+func (s *propertyFormService) purge(ctx context.Context, propertyID int64) error {
 	// Начало транзакции
 	tx, err := s.transactionManager.BeginTransaction(ctx)
 	if err != nil {
@@ -95,7 +148,7 @@ func (s *propertyFormService) DeletePropertyForm(ctx context.Context, propertyID
 	}()
 
 	// Удаление изображений, связанных с объектом
-	err = s.imageService.DeleteImagesByPropertyId(ctx, propertyID, tx)
+	images, err := s.imageService.DeleteImagesByPropertyId(ctx, propertyID, tx)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to delete images: %w", err)
@@ -116,5 +169,11 @@ func (s *propertyFormService) DeletePropertyForm(ctx context.Context, propertyID
 	}
 
 	// Коммит транзакции
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Файлы удаляем только после коммита, иначе при откате записи останутся без файлов
+	s.imageService.RemoveImageFiles(ctx, images)
+	return nil
 }
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	http2 "property-managment-service/internal/image/delivery/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	http3 "property-managment-service/internal/propdetails/delivery/http"
+	"property-managment-service/internal/property/delivery/http"
+	"property-managment-service/pkg/db/dbtest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type fakePropertyService struct {
+	http.PropertyService
+}
+
+func (fakePropertyService) SaveWithTx(_ context.Context, property *models.Property, _ *sqlx.Tx) error {
+	property.ID = 1
+	return nil
+}
+
+type fakeDetailsService struct {
+	http3.PropertyDetailsService
+}
+
+func (fakeDetailsService) SaveWithTx(context.Context, *models.PropertyDetails, *sqlx.Tx) error {
+	return nil
+}
+
+func (fakeDetailsService) DeleteWithTx(context.Context, int64, *sqlx.Tx) error {
+	return nil
+}
+
+// fakeImageService возвращает заданные изображения и запоминает, чьи файлы удалены
+type fakeImageService struct {
+	http2.ImageService
+	uploaded  []models.Image
+	uploadErr error
+	removed   []models.Image
+}
+
+func (s *fakeImageService) UploadImagesFromBase64(context.Context, []string, int64, *sqlx.Tx) ([]models.Image, error) {
+	if s.uploadErr != nil {
+		return nil, s.uploadErr
+	}
+	return s.uploaded, nil
+}
+
+func (s *fakeImageService) DeleteImagesByPropertyId(context.Context, int64, *sqlx.Tx) ([]models.Image, error) {
+	return nil, nil
+}
+
+func (s *fakeImageService) RemoveImageFiles(_ context.Context, images []models.Image) {
+	s.removed = append(s.removed, images...)
+}
+
+func TestSavePropertyFormRemovesFilesOnFailure(t *testing.T) {
+	uploaded := []models.Image{{Id: 1, ImageUrl: "properties/1/a.jpg"}, {Id: 2, ImageUrl: "properties/1/b.jpg"}}
+
+	tests := []struct {
+		name        string
+		commitErr   error
+		uploadErr   error
+		wantErr     bool
+		wantRemoved []models.Image
+	}{
+		{name: "commit succeeds"},
+		// Записи не сохранились, и файлы больше ни на что не ссылаются
+		{name: "commit fails", commitErr: errors.New("connection lost"), wantErr: true, wantRemoved: uploaded},
+		// Уже записанные файлы удаляет сам UploadImagesFromBase64
+		{name: "upload fails", uploadErr: errors.New("bad image"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := dbtest.NewTransactionManager()
+			tm.CommitErr = tt.commitErr
+			images := &fakeImageService{uploaded: uploaded, uploadErr: tt.uploadErr}
+			s := NewPropertyFormService(tm, fakePropertyService{}, images, fakeDetailsService{}, discardLog)
+
+			err := s.SavePropertyForm(context.Background(), &request.AddPropertyRequest{
+				Property:        &models.Property{},
+				PropertyDetails: &models.PropertyDetails{},
+				Images:          []string{"data:image/png;base64,AAAA", "data:image/png;base64,BBBB"},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(images.removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", images.removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+var errDeleteFailed = errors.New("property is still referenced")
+
+// fakeArchive хранит архивные объявления в порядке архивации; удаление id из broken не проходит
+type fakeArchive struct {
+	http.PropertyService
+	archived []int64
+	broken   map[int64]bool
+	queries  int
+}
+
+func (a *fakeArchive) GetArchivedBefore(_ context.Context, _ time.Time, exclude []int64, limit int) ([]int64, error) {
+	a.queries++
+	ids := []int64{}
+	for _, id := range a.archived {
+		if len(ids) < limit && !slices.Contains(exclude, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (a *fakeArchive) DeleteWithTx(_ context.Context, id int64, _ *sqlx.Tx) error {
+	if a.broken[id] {
+		return errDeleteFailed
+	}
+	a.archived = slices.DeleteFunc(a.archived, func(archived int64) bool { return archived == id })
+	return nil
+}
+
+// Упавшие объявления не останавливают очистку и не выбираются повторно, даже если ими занята целая партия
+func TestPurgeArchivedSkipsFailedProperties(t *testing.T) {
+	archive := &fakeArchive{broken: map[int64]bool{}}
+	for id := int64(1); id <= purgeBatchSize+50; id++ {
+		archive.archived = append(archive.archived, id)
+		if id <= purgeBatchSize {
+			archive.broken[id] = true
+		}
+	}
+	tm := dbtest.NewTransactionManager()
+	s := NewPropertyFormService(tm, archive, &fakeImageService{}, fakeDetailsService{}, discardLog)
+
+	purged, err := s.PurgeArchived(context.Background(), time.Now())
+	if purged != 50 || tm.Commits() != 50 {
+		t.Errorf("purged = %d with %d commits, want 50", purged, tm.Commits())
+	}
+	if !errors.Is(err, errDeleteFailed) {
+		t.Errorf("err = %v, want it to wrap the delete failures", err)
+	}
+	if archive.queries != 2 {
+		t.Errorf("queried archive %d times, want 2", archive.queries)
+	}
+	if len(archive.archived) != purgeBatchSize {
+		t.Errorf("%d properties left in archive, want the %d broken ones", len(archive.archived), purgeBatchSize)
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"net/http"
+	"property-managment-service/internal/middleware"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"strconv"
+)
+
+type ReviewService interface {
+	Create(ctx context.Context, userId int64, req *request.CreateReviewRequest) (*models.Review, error)
+	GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Review, error)
+	Delete(ctx context.Context, id int64, userId int64) (int64, error)
+	Moderate(ctx context.Context, id int64) (int64, error)
+	Reply(ctx context.Context, id int64, userId int64, reply string) (*models.Review, error)
+}
+
+type reviewHandlers struct {
+	reviewService ReviewService
+	log           *slog.Logger
+}
+
+func NewReviewHandlers(reviewService ReviewService, log *slog.Logger) ReviewHandlers {
+	return &reviewHandlers{reviewService: reviewService, log: log}
+}
+
+func (h *reviewHandlers) CreateReview() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling CreateReview", slog.String("request_id", requestID))
+		r := &request.CreateReviewRequest{}
+
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		review, err := h.reviewService.Create(ctx, claims.UserId, r)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, review)
+	}
+}
+
+func (h *reviewHandlers) GetReviews() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling GetReviews", slog.String("request_id", requestID))
+		propertyId, err := strconv.ParseInt(c.QueryParam("propertyId"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid propertyId")
+		}
+
+		reviews, err := h.reviewService.GetByPropertyId(ctx, propertyId)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, reviews)
+	}
+}
+
+func (h *reviewHandlers) DeleteReview() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling DeleteReview", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		deletedId, err := h.reviewService.Delete(ctx, id, claims.UserId)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, deletedId)
+	}
+}
+
+func (h *reviewHandlers) ReplyToReview() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := utils.GetRequestCtx(c)
+		requestID := utils.GetRequestID(c)
+		h.log.Info("Handling ReplyToReview", slog.String("request_id", requestID))
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			return httpErrors.NewBadRequestError("invalid id")
+		}
+
+		r := &request.ReviewReplyRequest{}
+		if err := utils.ReadRequest(c, r); err != nil {
+			return err
+		}
+
+		claims, err := middleware.GetClaims(c)
+		if err != nil {
+			return httpErrors.NewUnauthorizedError(err)
+		}
+
+		review, err := h.reviewService.Reply(ctx, id, claims.UserId, r.Reply)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, review)
+	}
+}
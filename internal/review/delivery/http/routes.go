@@ -0,0 +1,21 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"property-managment-service/internal/authz"
+	"property-managment-service/internal/middleware"
+)
+
+type ReviewHandlers interface {
+	CreateReview() echo.HandlerFunc
+	GetReviews() echo.HandlerFunc
+	DeleteReview() echo.HandlerFunc
+	ReplyToReview() echo.HandlerFunc
+}
+
+func MapReviewRoutes(reviewGroup *echo.Group, h ReviewHandlers, mw *middleware.MiddlewareManager) {
+	reviewGroup.POST("", h.CreateReview(), mw.AuthJWTMiddleware(), mw.RequireRole(authz.RoleGuest))
+	reviewGroup.GET("", h.GetReviews())
+	reviewGroup.DELETE("/:id", h.DeleteReview(), mw.AuthJWTMiddleware())
+	reviewGroup.POST("/:id/reply", h.ReplyToReview(), mw.AuthJWTMiddleware())
+}
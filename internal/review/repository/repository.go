@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/review/service"
+)
+
+type reviewRepository struct {
+	Db *sqlx.DB
+}
+
+func NewReviewRepository(db *sqlx.DB) service.ReviewRepository {
+	return &reviewRepository{Db: db}
+}
+
+func (r *reviewRepository) CreateWithTx(ctx context.Context, review *models.Review, tx *sqlx.Tx) (*models.Review, error) {
+	const op = "reviewRepository.CreateWithTx"
+	query := `INSERT INTO reviews (property_id, user_id, booking_id, rating, comment, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING *`
+	if err := tx.QueryRowxContext(ctx, query, review.PropertyId, review.UserId, review.BookingId, review.Rating,
+		review.Comment, review.CreatedAt).StructScan(review); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return review, nil
+}
+
+func (r *reviewRepository) GetById(ctx context.Context, id int64) (*models.Review, error) {
+	const op = "reviewRepository.GetById"
+	query := `SELECT * FROM reviews WHERE id = $1`
+	review := &models.Review{}
+	if err := r.Db.QueryRowxContext(ctx, query, id).StructScan(review); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return review, nil
+}
+
+func (r *reviewRepository) ExistsByBookingId(ctx context.Context, bookingId int64) (bool, error) {
+	const op = "reviewRepository.ExistsByBookingId"
+	query := `SELECT EXISTS (SELECT 1 FROM reviews WHERE booking_id = $1)`
+	var exists bool
+	if err := r.Db.QueryRowxContext(ctx, query, bookingId).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exists, nil
+}
+
+func (r *reviewRepository) GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Review, error) {
+	const op = "reviewRepository.GetByPropertyId"
+	query := `SELECT * FROM reviews WHERE property_id = $1 ORDER BY created_at DESC, id DESC`
+	rows, err := r.Db.QueryxContext(ctx, query, propertyId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	reviews := []*models.Review{}
+	for rows.Next() {
+		review := &models.Review{}
+		if err := rows.StructScan(review); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reviews, nil
+}
+
+func (r *reviewRepository) DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error {
+	const op = "reviewRepository.DeleteWithTx"
+	query := `DELETE FROM reviews WHERE id = $1 RETURNING id`
+	var deletedID int64
+	if err := tx.QueryRowxContext(ctx, query, id).Scan(&deletedID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetReply сохраняет ответ владельца, если его ещё нет. Возвращает sql.ErrNoRows, если ответ уже был.
+func (r *reviewRepository) SetReply(ctx context.Context, id int64, reply string) (*models.Review, error) {
+	const op = "reviewRepository.SetReply"
+	query := `UPDATE reviews SET reply = $1, replied_at = NOW() WHERE id = $2 AND reply IS NULL RETURNING *`
+	review := &models.Review{}
+	if err := r.Db.QueryRowxContext(ctx, query, reply, id).StructScan(review); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return review, nil
+}
+
+// LockPropertyWithTx блокирует строку объекта до конца транзакции, чтобы отзывы объекта
+// добавлялись и удалялись по очереди и пересчёт сводки видел изменения соседних транзакций
+func (r *reviewRepository) LockPropertyWithTx(ctx context.Context, propertyId int64, tx *sqlx.Tx) error {
+	const op = "reviewRepository.LockPropertyWithTx"
+	query := `SELECT id FROM properties WHERE id = $1 FOR UPDATE`
+	var id int64
+	if err := tx.QueryRowxContext(ctx, query, propertyId).Scan(&id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UpdateRatingSummaryWithTx пересчитывает средний рейтинг и количество отзывов объекта.
+// Версию объекта не меняет: сводка не редактируется владельцем и не участвует в If-Match.
+func (r *reviewRepository) UpdateRatingSummaryWithTx(ctx context.Context, propertyId int64, tx *sqlx.Tx) error {
+	const op = "reviewRepository.UpdateRatingSummaryWithTx"
+	query := `UPDATE properties p
+			  SET rating = s.rating, review_count = s.review_count
+			  FROM (SELECT COALESCE(ROUND(AVG(rating), 2), 0) AS rating, COUNT(*) AS review_count
+					FROM reviews WHERE property_id = $1) s
+			  WHERE p.id = $1`
+	if _, err := tx.ExecContext(ctx, query, propertyId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
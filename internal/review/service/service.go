@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+	"net/http"
+	bookingHttp "property-managment-service/internal/booking/delivery/http"
+	"property-managment-service/internal/models"
+	"property-managment-service/internal/models/request"
+	propertyHttp "property-managment-service/internal/property/delivery/http"
+	reviewHttp "property-managment-service/internal/review/delivery/http"
+	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/httpErrors"
+	"property-managment-service/pkg/utils"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type ReviewRepository interface {
+	CreateWithTx(ctx context.Context, review *models.Review, tx *sqlx.Tx) (*models.Review, error)
+	GetById(ctx context.Context, id int64) (*models.Review, error)
+	ExistsByBookingId(ctx context.Context, bookingId int64) (bool, error)
+	GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Review, error)
+	DeleteWithTx(ctx context.Context, id int64, tx *sqlx.Tx) error
+	SetReply(ctx context.Context, id int64, reply string) (*models.Review, error)
+	LockPropertyWithTx(ctx context.Context, propertyId int64, tx *sqlx.Tx) error
+	UpdateRatingSummaryWithTx(ctx context.Context, propertyId int64, tx *sqlx.Tx) error
+}
+
+type reviewService struct {
+	log                *slog.Logger
+	reviewRepo         ReviewRepository
+	bookingService     bookingHttp.BookingService
+	propertyService    propertyHttp.PropertyService
+	transactionManager db.TransactionManager
+}
+
+func NewReviewService(
+	reviewRepo ReviewRepository,
+	bookingService bookingHttp.BookingService,
+	propertyService propertyHttp.PropertyService,
+	transactionManager db.TransactionManager,
+	log *slog.Logger,
+) reviewHttp.ReviewService {
+	return &reviewService{
+		log:                log,
+		reviewRepo:         reviewRepo,
+		bookingService:     bookingService,
+		propertyService:    propertyService,
+		transactionManager: transactionManager,
+	}
+}
+
+// Create сохраняет отзыв гостя. Оставить отзыв можно один раз на каждое завершённое подтверждённое бронирование.
+func (s *reviewService) Create(ctx context.Context, userId int64, req *request.CreateReviewRequest) (*models.Review, error) {
+	booking, err := s.bookingService.GetById(ctx, req.BookingId)
+	if err != nil {
+		return nil, err
+	}
+
+	if booking.UserId != userId {
+		return nil, httpErrors.NewForbiddenError(nil)
+	}
+
+	today := time.Now().Format(dateLayout)
+	if booking.Status != "confirmed" || booking.CheckOutDate > today {
+		return nil, httpErrors.NewRestError(http.StatusForbidden, "only guests with a completed booking can leave a review", nil)
+	}
+
+	exists, err := s.reviewRepo.ExistsByBookingId(ctx, booking.Id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, httpErrors.NewRestError(http.StatusConflict, "booking has already been reviewed", nil)
+	}
+
+	review := &models.Review{
+		PropertyId: booking.PropertyId,
+		UserId:     userId,
+		BookingId:  &booking.Id,
+		Rating:     req.Rating,
+		Comment:    req.Comment,
+		CreatedAt:  today,
+	}
+
+	err = s.withSummary(ctx, booking.PropertyId, func(tx *sqlx.Tx) error {
+		review, err = s.reviewRepo.CreateWithTx(ctx, review, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return formatDates(review), nil
+}
+
+func (s *reviewService) GetByPropertyId(ctx context.Context, propertyId int64) ([]*models.Review, error) {
+	reviews, err := s.reviewRepo.GetByPropertyId(ctx, propertyId)
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		formatDates(review)
+	}
+	return reviews, nil
+}
+
+func (s *reviewService) Delete(ctx context.Context, id int64, userId int64) (int64, error) {
+	review, err := s.reviewRepo.GetById(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if review.UserId != userId {
+		return 0, httpErrors.NewForbiddenError(nil)
+	}
+	return s.remove(ctx, review)
+}
+
+// Moderate удаляет отзыв без проверки автора; доступно только администратору
+func (s *reviewService) Moderate(ctx context.Context, id int64) (int64, error) {
+	review, err := s.reviewRepo.GetById(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return s.remove(ctx, review)
+}
+
+func (s *reviewService) remove(ctx context.Context, review *models.Review) (int64, error) {
+	err := s.withSummary(ctx, review.PropertyId, func(tx *sqlx.Tx) error {
+		return s.reviewRepo.DeleteWithTx(ctx, review.Id, tx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.log.Info("Delete", "review id", review.Id)
+	return review.Id, nil
+}
+
+// Reply сохраняет публичный ответ владельца объекта. На каждый отзыв допускается один ответ.
+func (s *reviewService) Reply(ctx context.Context, id int64, userId int64, reply string) (*models.Review, error) {
+	review, err := s.reviewRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.propertyService.GetById(ctx, review.PropertyId)
+	if err != nil {
+		return nil, err
+	}
+	if property.OwnerId != userId {
+		return nil, httpErrors.NewForbiddenError(nil)
+	}
+
+	review, err = s.reviewRepo.SetReply(ctx, id, reply)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, httpErrors.NewRestError(http.StatusConflict, "review already has a reply", nil)
+		}
+		return nil, err
+	}
+	return formatDates(review), nil
+}
+
+// withSummary выполняет fn и пересчёт сводки рейтинга объекта в одной транзакции под блокировкой объекта
+func (s *reviewService) withSummary(ctx context.Context, propertyId int64, fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.transactionManager.BeginTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	// Без блокировки две параллельные транзакции посчитали бы сводку каждая по своему снимку без чужого отзыва
+	if err := s.reviewRepo.LockPropertyWithTx(ctx, propertyId, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := s.reviewRepo.UpdateRatingSummaryWithTx(ctx, propertyId, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func formatDates(review *models.Review) *models.Review {
+	if formatted, err := utils.ParseDate(&review.CreatedAt); err == nil {
+		review.CreatedAt = formatted
+	}
+	return review
+}
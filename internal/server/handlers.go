@@ -1,13 +1,32 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"net/http"
+	adminHttp "property-managment-service/internal/admin/delivery/http"
+	apiKeyRepository "property-managment-service/internal/apikey/repository"
+	apiKey "property-managment-service/internal/apikey/service"
+	auditRepository "property-managment-service/internal/audit/repository"
+	audit "property-managment-service/internal/audit/service"
+	"property-managment-service/internal/authz"
+	authzRepository "property-managment-service/internal/authz/repository"
+	availabilityHttp "property-managment-service/internal/availability/delivery/http"
+	availabilityRepository "property-managment-service/internal/availability/repository"
+	availability "property-managment-service/internal/availability/service"
+	bookingHttp "property-managment-service/internal/booking/delivery/http"
+	bookingRepository "property-managment-service/internal/booking/repository"
+	booking "property-managment-service/internal/booking/service"
+	bookingWorker "property-managment-service/internal/booking/worker"
+	"property-managment-service/internal/geocoding"
 	imageHttp "property-managment-service/internal/image/delivery/http"
 	repository2 "property-managment-service/internal/image/delivery/repository"
+	imageGC "property-managment-service/internal/image/gc"
 	image "property-managment-service/internal/image/service"
+	"property-managment-service/internal/image/storage"
+	imageWorker "property-managment-service/internal/image/worker"
 	middleware2 "property-managment-service/internal/middleware"
 	propDetailsHttp "property-managment-service/internal/propdetails/delivery/http"
 	repository3 "property-managment-service/internal/propdetails/repository"
@@ -15,27 +34,62 @@ import (
 	propertyHttp "property-managment-service/internal/property/delivery/http"
 	"property-managment-service/internal/property/repository"
 	property "property-managment-service/internal/property/service"
+	propertyWorker "property-managment-service/internal/property/worker"
 	"property-managment-service/internal/propertyform/service"
+	reviewHttp "property-managment-service/internal/review/delivery/http"
+	reviewRepository "property-managment-service/internal/review/repository"
+	review "property-managment-service/internal/review/service"
 	"property-managment-service/pkg/db"
+	"property-managment-service/pkg/jwks"
 	"property-managment-service/pkg/utils"
 )
 
-func (s *Server) MapHandlers(e *echo.Echo) error {
+func (s *Server) MapHandlers(ctx context.Context, e *echo.Echo) error {
 	propertyRepo := repository.NewPropertyRepository(s.db)
 	imageRepo := repository2.NewImageRepository(s.db)
 	propertyDetailsRepo := repository3.NewPropDetailsRepository(s.db)
+	bookingRepo := bookingRepository.NewBookingRepository(s.db)
+	availabilityRepo := availabilityRepository.NewAvailabilityRepository(s.db)
+	reviewRepo := reviewRepository.NewReviewRepository(s.db)
 	transactionManager := db.NewTransactionManager(s.db)
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(s.db), s.log)
+	authorizer := authz.NewAuthorizer(authzRepository.NewOwnerRepository(s.db))
 
-	propertyService := property.NewPropertyService(propertyRepo, s.log)
-	propertyDetailsService := propertyDetails.NewPropertyDetailsService(propertyDetailsRepo, s.log)
-	imageService := image.NewImageService(imageRepo, s.log)
-	propertyFormService := service.NewPropertyFormService(transactionManager, propertyService, imageService, propertyDetailsService)
+	geocoder, err := s.newGeocoder()
+	if err != nil {
+		return err
+	}
+
+	imageStorage, err := storage.New(ctx, s.cfg.Storage)
+	if err != nil {
+		return err
+	}
+
+	propertyService := property.NewPropertyService(propertyRepo, geocoder, imageStorage, transactionManager, s.cfg.Properties, auditService, s.log)
+	propertyDetailsService := propertyDetails.NewPropertyDetailsService(propertyDetailsRepo, transactionManager, auditService, s.log)
+	imageService := image.NewImageService(imageRepo, imageStorage, transactionManager, s.cfg.Images, auditService, s.log)
+	propertyFormService := service.NewPropertyFormService(transactionManager, propertyService, imageService, propertyDetailsService, s.log)
+	bookingService := booking.NewBookingService(bookingRepo, propertyService, transactionManager, s.cfg.Booking.PendingTTL, auditService, s.log)
+	reviewService := review.NewReviewService(reviewRepo, bookingService, propertyService, transactionManager, s.log)
+	availabilityService := availability.NewAvailabilityService(availabilityRepo, propertyService, transactionManager, s.log)
 
-	propertyHandlers := propertyHttp.NewPropertyHandlers(propertyService, propertyFormService, s.cfg, s.log)
+	propertyHandlers := propertyHttp.NewPropertyHandlers(propertyService, propertyFormService, authorizer, s.cfg, s.log)
 	imageHandlers := imageHttp.NewImageHandlers(s.cfg, imageService, s.log)
-	propertyDetailsHandlers := propDetailsHttp.NewPropertyDetailsHandlers(propertyDetailsService, s.log)
+	propertyDetailsHandlers := propDetailsHttp.NewPropertyDetailsHandlers(propertyDetailsService, authorizer, s.log)
+	bookingHandlers := bookingHttp.NewBookingHandlers(bookingService, authorizer, s.log)
+	availabilityHandlers := availabilityHttp.NewAvailabilityHandlers(availabilityService, s.log)
+	reviewHandlers := reviewHttp.NewReviewHandlers(reviewService, s.log)
 
-	mw := middleware2.NewMiddlewareManager(s.log, s.cfg)
+	gcCfg := s.cfg.Images.GC
+	collector := imageGC.NewCollector(imageRepo, imageStorage, transactionManager, gcCfg.GracePeriod, s.log)
+	adminHandlers := adminHttp.NewAdminHandlers(reviewService, propertyFormService, propertyService, collector, auditService, s.log)
+
+	keys, err := s.newKeySet()
+	if err != nil {
+		return err
+	}
+	apiKeyService := apiKey.NewApiKeyService(apiKeyRepository.NewApiKeyRepository(s.db), s.log)
+	mw := middleware2.NewMiddlewareManager(s.log, s.cfg, authorizer, keys, apiKeyService)
 
 	allowedOrigins := "http://localhost:3000"
 	if s.cfg.App.Env == "prod" {
@@ -43,9 +97,12 @@ func (s *Server) MapHandlers(e *echo.Echo) error {
 	}
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{allowedOrigins},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
-		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowOrigins: []string{allowedOrigins},
+		AllowHeaders: []string{"Content-Type", "Authorization", middleware2.HeaderAPIKey,
+			utils.HeaderIfMatch, utils.HeaderIfNoneMatch},
+		// Без этого браузер не покажет клиенту ETag, нужный для If-Match
+		ExposeHeaders:    []string{utils.HeaderETag},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
 		AllowCredentials: true, // разрешает отправку учетных данных
 	}))
 
@@ -54,16 +111,59 @@ func (s *Server) MapHandlers(e *echo.Echo) error {
 	propertyGroup := v1.Group("/properties")
 	imageGroup := v1.Group("/images")
 	propertyDetailsGroup := v1.Group("/prop-details")
+	bookingGroup := v1.Group("/bookings")
+	reviewGroup := v1.Group("/reviews")
+	adminGroup := v1.Group("/admin")
 
 	propertyHttp.MapPropertyRoutes(propertyGroup, propertyHandlers, mw)
 	imageHttp.MapImageRoutes(imageGroup, imageHandlers, mw)
+	imageHttp.MapPropertyImageRoutes(propertyGroup, imageHandlers, mw)
 	propDetailsHttp.MapPropertyDetailsRoutes(propertyDetailsGroup, propertyDetailsHandlers, mw)
+	bookingHttp.MapBookingRoutes(bookingGroup, bookingHandlers, mw)
+	availabilityHttp.MapAvailabilityRoutes(propertyGroup, availabilityHandlers, mw)
+	reviewHttp.MapReviewRoutes(reviewGroup, reviewHandlers, mw)
+	adminHttp.MapAdminRoutes(adminGroup, adminHandlers, mw)
 
 	health.GET("", func(c echo.Context) error {
 		s.log.Info(fmt.Sprintf("Health check RequestID: %s", utils.GetRequestID(c)))
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
+	go bookingWorker.NewExpirationWorker(bookingService, s.cfg.Booking.ExpirationInterval, s.log).Run(ctx)
+	if purgeCfg := s.cfg.Properties.Purge; purgeCfg.Enabled {
+		go propertyWorker.NewPurgeWorker(propertyFormService, purgeCfg.Interval, purgeCfg.Retention, s.log).Run(ctx)
+	}
+	if gcCfg.Enabled {
+		go imageWorker.NewGCWorker(collector, gcCfg.Interval, gcCfg.DryRun, s.log).Run(ctx)
+	}
+
 	return nil
 
 }
+
+// newKeySet возвращает набор ключей для RS256/ES256 или nil, если JWKS не настроен
+func (s *Server) newKeySet() (*jwks.KeySet, error) {
+	cfg := s.cfg.Auth.JWKS
+	switch {
+	case cfg.File != "" && cfg.URL != "":
+		return nil, fmt.Errorf("auth.jwks: file and url are mutually exclusive")
+	case cfg.File != "":
+		return jwks.NewKeySet(jwks.FileSource(cfg.File), cfg.CacheTTL), nil
+	case cfg.URL != "":
+		client := &http.Client{Timeout: cfg.Timeout}
+		return jwks.NewKeySet(jwks.URLSource(cfg.URL, client), cfg.CacheTTL), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Server) newGeocoder() (geocoding.Geocoder, error) {
+	switch s.cfg.Geocoding.Provider {
+	case "fixture":
+		return geocoding.NewFixtureGeocoder(s.cfg.Geocoding.FixturesPath)
+	case "none", "":
+		return geocoding.NewNoopGeocoder(), nil
+	default:
+		return nil, fmt.Errorf("unknown geocoding provider %q", s.cfg.Geocoding.Provider)
+	}
+}
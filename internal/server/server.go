@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"property-managment-service/internal/config"
 	"property-managment-service/lib/sl"
+	"property-managment-service/pkg/utils"
 	"strconv"
 	"syscall"
 	"time"
@@ -34,6 +35,8 @@ func NewServer(cfg *config.Config, db *sqlx.DB, log *slog.Logger) *Server {
 }
 
 func (s *Server) Run() error {
+	s.echo.HTTPErrorHandler = utils.NewHTTPErrorHandler(s.log)
+	s.echo.Use(middleware.RequestID())
 	s.echo.Use(middleware.BodyLimit("20M"))
 	server := &http.Server{
 		Addr:         ":" + strconv.Itoa(s.cfg.Server.Port),
@@ -44,7 +47,7 @@ func (s *Server) Run() error {
 	go func() {
 		sl.Infof(s.log, "Server is listening on PORT: %d", s.cfg.Server.Port)
 		if err := s.echo.StartServer(server); err != nil {
-			s.log.Error("Error starting Server: ", err)
+			s.log.Error("Error starting Server", sl.Err(err))
 			os.Exit(1)
 		}
 	}()
@@ -52,12 +55,15 @@ func (s *Server) Run() error {
 	go func() {
 		sl.Infof(s.log, "Starting Debug Server on PORT: %s", PprofPort)
 		if err := http.ListenAndServe(":"+PprofPort, http.DefaultServeMux); err != nil {
-			s.log.Error("Error PPROF ListenAndServe: %s", err)
+			s.log.Error("Error PPROF ListenAndServe", sl.Err(err))
 			os.Exit(1)
 		}
 	}()
 
-	if err := s.MapHandlers(s.echo); err != nil {
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	if err := s.MapHandlers(workersCtx, s.echo); err != nil {
 		return err
 	}
 
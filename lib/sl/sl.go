@@ -13,5 +13,5 @@ func Err(err error) slog.Attr {
 }
 
 func Infof(log *slog.Logger, format string, args ...any) {
-	log.Info(fmt.Sprintf(format, args))
+	log.Info(fmt.Sprintf(format, args...))
 }
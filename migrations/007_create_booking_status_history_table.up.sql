@@ -0,0 +1,15 @@
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('confirmed', 'pending', 'cancelled', 'declined'));
+
+CREATE TABLE booking_status_history (
+                                        id BIGSERIAL PRIMARY KEY,
+                                        booking_id BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+                                        from_status TEXT NOT NULL,
+                                        to_status TEXT NOT NULL,
+                                        changed_by BIGINT, -- NULL, если переход выполнен системой
+                                        reason TEXT NOT NULL DEFAULT '',
+                                        changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX booking_status_history_booking_id_idx ON booking_status_history (booking_id);
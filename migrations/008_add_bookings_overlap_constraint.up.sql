@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE bookings ADD CONSTRAINT bookings_dates_check CHECK (check_out_date > check_in_date);
+
+-- Два активных бронирования одного объекта не могут пересекаться по датам.
+-- Дата выезда не входит в диапазон, поэтому заезд в день выезда предыдущего гостя допустим.
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (
+        property_id WITH =,
+        daterange(check_in_date, check_out_date, '[)') WITH &&
+    ) WHERE (status NOT IN ('cancelled', 'declined'));
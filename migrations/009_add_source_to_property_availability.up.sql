@@ -0,0 +1,5 @@
+-- Источник блокировки: 'manual' - закрыто владельцем, иначе имя внешнего календаря,
+-- из которого даты были импортированы (повторный импорт заменяет строки этого источника)
+ALTER TABLE property_availability ADD COLUMN source TEXT NOT NULL DEFAULT 'manual';
+
+CREATE INDEX property_availability_source_idx ON property_availability (property_id, source);
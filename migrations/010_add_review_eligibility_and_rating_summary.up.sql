@@ -0,0 +1,18 @@
+ALTER TABLE reviews ADD COLUMN booking_id BIGINT REFERENCES bookings(id) ON DELETE SET NULL;
+ALTER TABLE reviews ADD CONSTRAINT reviews_booking_id_key UNIQUE (booking_id);
+ALTER TABLE reviews ADD COLUMN reply TEXT;
+ALTER TABLE reviews ADD COLUMN replied_at TIMESTAMPTZ;
+
+CREATE INDEX reviews_property_id_idx ON reviews (property_id);
+
+-- Денормализованная сводка по отзывам, пересчитывается при изменении отзывов
+ALTER TABLE properties ADD COLUMN rating NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE properties ADD COLUMN review_count INT NOT NULL DEFAULT 0;
+
+UPDATE properties p
+SET rating       = s.rating,
+    review_count = s.review_count
+FROM (SELECT property_id, ROUND(AVG(rating), 2) AS rating, COUNT(*) AS review_count
+      FROM reviews
+      GROUP BY property_id) s
+WHERE p.id = s.property_id;
@@ -0,0 +1,4 @@
+-- Индексы под keyset-пагинацию: (колонка сортировки, id)
+CREATE INDEX properties_price_id_idx ON properties (price, id);
+CREATE INDEX properties_created_at_id_idx ON properties (created_at, id);
+CREATE INDEX properties_rating_id_idx ON properties (rating, id);
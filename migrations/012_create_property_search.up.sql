@@ -0,0 +1,49 @@
+-- Поисковый документ объявления: заголовок, адрес и описание из property_details.
+-- Конфигурация 'russian' стеммит кириллицу через russian_stem, а латиницу (asciiword) через english_stem,
+-- поэтому одного документа достаточно для русских и английских текстов.
+CREATE TABLE property_search (
+                                 property_id BIGINT PRIMARY KEY REFERENCES properties(id) ON DELETE CASCADE,
+                                 document TSVECTOR NOT NULL
+);
+
+CREATE INDEX property_search_document_idx ON property_search USING GIN (document);
+
+CREATE FUNCTION refresh_property_search(pid BIGINT) RETURNS void AS $$
+    INSERT INTO property_search (property_id, document)
+    SELECT p.id,
+           setweight(to_tsvector('russian', coalesce(p.title, '')), 'A') ||
+           setweight(to_tsvector('russian', coalesce(p.location, '')), 'B') ||
+           setweight(to_tsvector('russian', coalesce(d.description, '')), 'C')
+    FROM properties p
+             LEFT JOIN property_details d ON d.property_id = p.id
+    WHERE p.id = pid
+    ON CONFLICT (property_id) DO UPDATE SET document = EXCLUDED.document;
+$$ LANGUAGE sql;
+
+CREATE FUNCTION properties_search_trigger() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_property_search(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION property_details_search_trigger() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_property_search(OLD.property_id);
+    ELSE
+        PERFORM refresh_property_search(NEW.property_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER properties_search_refresh
+    AFTER INSERT OR UPDATE OF title, location ON properties
+    FOR EACH ROW EXECUTE FUNCTION properties_search_trigger();
+
+CREATE TRIGGER property_details_search_refresh
+    AFTER INSERT OR UPDATE OF description OR DELETE ON property_details
+    FOR EACH ROW EXECUTE FUNCTION property_details_search_trigger();
+
+SELECT refresh_property_search(id) FROM properties;
@@ -0,0 +1,14 @@
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+ALTER TABLE properties ADD COLUMN latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90);
+ALTER TABLE properties ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180);
+ALTER TABLE properties ADD CONSTRAINT properties_coordinates_check
+    CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+-- Поиск в радиусе: earth_box(...) @> ll_to_earth(latitude, longitude)
+CREATE INDEX properties_earth_idx ON properties USING gist (ll_to_earth(latitude, longitude))
+    WHERE latitude IS NOT NULL;
+-- Поиск в прямоугольной области карты
+CREATE INDEX properties_coordinates_idx ON properties (latitude, longitude)
+    WHERE latitude IS NOT NULL;
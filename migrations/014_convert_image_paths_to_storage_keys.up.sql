@@ -0,0 +1,5 @@
+-- Раньше в image_url хранился абсолютный путь к файлу на диске, теперь - ключ в хранилище.
+-- Файлы нужно перенести в корень хранилища под properties/<property_id>/<имя файла>.
+UPDATE properties_images
+SET image_url = 'properties/' || property_id || '/' || regexp_replace(image_url, '^.*/', '')
+WHERE image_url LIKE '/%';
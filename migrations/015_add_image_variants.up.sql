@@ -0,0 +1,2 @@
+-- Метаданные уменьшенных копий: {"thumb": {"key": ..., "width": ..., "height": ..., "bytes": ...}, ...}
+ALTER TABLE properties_images ADD COLUMN variants JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+-- Размеры и объём исходного файла; bytes используется для квоты на объект недвижимости
+ALTER TABLE properties_images
+    ADD COLUMN width INT NOT NULL DEFAULT 0,
+    ADD COLUMN height INT NOT NULL DEFAULT 0,
+    ADD COLUMN bytes BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+ALTER TABLE properties_images
+    ADD COLUMN position INT NOT NULL DEFAULT 0,
+    ADD COLUMN is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN caption TEXT NOT NULL DEFAULT '';
+
+-- Существующие изображения упорядочиваем по порядку загрузки, обложкой становится первое
+UPDATE properties_images i
+SET position = o.position
+FROM (SELECT id, row_number() OVER (PARTITION BY property_id ORDER BY id) - 1 AS position
+      FROM properties_images) o
+WHERE i.id = o.id;
+
+UPDATE properties_images SET is_cover = TRUE WHERE position = 0;
+
+CREATE UNIQUE INDEX properties_images_cover_idx ON properties_images (property_id) WHERE is_cover;
+CREATE INDEX properties_images_position_idx ON properties_images (property_id, position);
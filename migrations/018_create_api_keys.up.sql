@@ -0,0 +1,12 @@
+-- Ключи доступа внутренних сервисов. Хранится только SHA-256 ключа; prefix - первые символы для поиска в списке
+CREATE TABLE api_keys
+(
+    id           BIGSERIAL PRIMARY KEY,
+    name         TEXT        NOT NULL UNIQUE,
+    prefix       TEXT        NOT NULL,
+    key_hash     TEXT        NOT NULL UNIQUE,
+    scopes       JSONB       NOT NULL DEFAULT '[]',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
@@ -0,0 +1,3 @@
+-- Версия для оптимистичной блокировки: увеличивается при каждом изменении строки и отдаётся клиенту в ETag
+ALTER TABLE properties ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE property_details ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Удалённые объявления архивируются: брони и отзывы остаются, строку удаляет только задача очистки
+ALTER TABLE properties ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX properties_deleted_at_idx ON properties (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Статус публикации. Уже существующие объявления остаются опубликованными, новые создаются черновиками
+ALTER TABLE properties
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+        CHECK (status IN ('draft', 'pending_review', 'published', 'suspended')),
+    ADD COLUMN status_reason TEXT;
+
+ALTER TABLE properties ALTER COLUMN status SET DEFAULT 'draft';
+
+CREATE INDEX properties_status_idx ON properties (status) WHERE status <> 'published';
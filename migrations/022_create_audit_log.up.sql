@@ -0,0 +1,30 @@
+-- Журнал изменений объявлений, деталей, изображений и бронирований.
+-- actor_id - пользователь из JWT, actor_key - имя API-ключа; оба NULL у фоновых задач.
+-- diff хранит только изменившиеся поля: {"before": {...}, "after": {...}}
+CREATE TABLE audit_log
+(
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor_id   BIGINT,
+    actor_key  TEXT,
+    request_id TEXT        NOT NULL DEFAULT '',
+    action     TEXT        NOT NULL,
+    entity     TEXT        NOT NULL,
+    entity_id  BIGINT      NOT NULL,
+    diff       JSONB       NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, id);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_id, id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- Журнал только дополняется
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,5 @@
+-- Каждый источник хранит свои строки: дата закрыта, если есть хотя бы одна строка.
+-- Иначе повторный импорт одного календаря освобождал бы даты, закрытые другим.
+ALTER TABLE property_availability DROP CONSTRAINT property_availability_property_id_date_key;
+ALTER TABLE property_availability
+    ADD CONSTRAINT property_availability_property_id_date_source_key UNIQUE (property_id, date, source);
@@ -0,0 +1,8 @@
+-- Момент создания заявки: created_at хранит только дату, и срок pending_ttl отсчитывался от полуночи.
+-- Для старых заявок точное время неизвестно, берём начало дня создания.
+ALTER TABLE bookings ADD COLUMN requested_at TIMESTAMPTZ;
+UPDATE bookings SET requested_at = created_at::timestamptz;
+ALTER TABLE bookings ALTER COLUMN requested_at SET DEFAULT NOW(),
+                     ALTER COLUMN requested_at SET NOT NULL;
+
+CREATE INDEX bookings_pending_requested_at_idx ON bookings (requested_at) WHERE status = 'pending';
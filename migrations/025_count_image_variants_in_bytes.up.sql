@@ -0,0 +1,3 @@
+-- bytes теперь включает варианты: по этой сумме считается квота объекта
+UPDATE properties_images
+SET bytes = bytes + (SELECT COALESCE(SUM((variant ->> 'bytes')::BIGINT), 0) FROM jsonb_each(variants) AS v(name, variant));
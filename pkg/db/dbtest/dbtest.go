@@ -0,0 +1,103 @@
+// Package dbtest содержит TransactionManager для тестов сервисов без базы данных:
+// транзакции настоящие (*sqlx.Tx), но запросы в них не выполняются, кроме команд SET.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"property-managment-service/pkg/db"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNoQueries = errors.New("dbtest: queries are not supported")
+
+// TransactionManager считает завершённые транзакции. CommitErr, если задан, возвращается из Commit.
+type TransactionManager struct {
+	db        *sqlx.DB
+	CommitErr error
+	commits   atomic.Int64
+	rollbacks atomic.Int64
+}
+
+var _ db.TransactionManager = (*TransactionManager)(nil)
+
+func NewTransactionManager() *TransactionManager {
+	tm := &TransactionManager{}
+	tm.db = sqlx.NewDb(sql.OpenDB(connector{tm: tm}), "dbtest")
+	return tm
+}
+
+func (tm *TransactionManager) BeginTransaction(ctx context.Context) (*sqlx.Tx, error) {
+	return tm.db.BeginTxx(ctx, &sql.TxOptions{})
+}
+
+func (tm *TransactionManager) Commits() int64 {
+	return tm.commits.Load()
+}
+
+func (tm *TransactionManager) Rollbacks() int64 {
+	return tm.rollbacks.Load()
+}
+
+type connector struct {
+	tm *TransactionManager
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{tm: c.tm}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return c
+}
+
+// Open нужен только для интерфейса driver.Driver: соединения создаёт Connect
+func (c connector) Open(string) (driver.Conn, error) {
+	return c.Connect(context.Background())
+}
+
+type conn struct {
+	tm *TransactionManager
+}
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, ErrNoQueries
+}
+
+// ExecContext принимает команды SET, которыми сервисы настраивают транзакцию; данные идут через фейковые репозитории
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) == 0 && strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SET ") {
+		return driver.ResultNoRows, nil
+	}
+	return nil, ErrNoQueries
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return &tx{tm: c.tm}, nil
+}
+
+type tx struct {
+	tm *TransactionManager
+}
+
+func (t *tx) Commit() error {
+	if t.tm.CommitErr != nil {
+		return t.tm.CommitErr
+	}
+	t.tm.commits.Add(1)
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.tm.rollbacks.Add(1)
+	return nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"net/http"
+	"property-managment-service/pkg/httpErrors"
+	"sort"
+	"strings"
+)
+
+// SetClause собирает "col1 = $n, col2 = $n+1" для UPDATE по колонкам частичного обновления.
+// Имена колонок подставляются в запрос как есть, поэтому должны приходить из кода (тегов db), а не от клиента.
+func SetClause(columns map[string]interface{}, firstArg int) (string, []interface{}) {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s = $%d", name, firstArg+i)
+		args[i] = columns[name]
+	}
+	return strings.Join(parts, ", "), args
+}
+
+// VersionCondition - условие оптимистичной блокировки для параметра $n со списком версий из If-Match;
+// NULL (If-Match: *) пропускает любую версию
+func VersionCondition(n int) string {
+	return fmt.Sprintf(`($%d::bigint[] IS NULL OR version = ANY($%d::bigint[]))`, n, n)
+}
+
+// UpdateMiss объясняет, почему условный UPDATE не вернул строку: её нет (sql.ErrNoRows)
+// или версия не совпала с If-Match (412)
+func UpdateMiss(ctx context.Context, q sqlx.QueryerContext, table, idColumn string, id int64) error {
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE %s = $1)`, table, idColumn)
+	if err := sqlx.GetContext(ctx, q, &exists, query, id); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return httpErrors.NewRestErrorFrom(http.StatusPreconditionFailed, httpErrors.PreconditionFailed, nil)
+}
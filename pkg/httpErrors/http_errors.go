@@ -4,35 +4,95 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
-const (
-	ErrEmailAlreadyExists = "User with given email already exists"
+var (
+	BadRequest           = errors.New("Bad Request")
+	Unauthorized         = errors.New("Unauthorized")
+	Forbidden            = errors.New("Forbidden")
+	InvalidJWTToken      = errors.New("Invalid JWT token")
+	ErrNoCookie          = errors.New("No cookie")
+	InvalidJWTClaims     = errors.New("Invalid JWT claims")
+	NotFound             = errors.New("Not Found")
+	Conflict             = errors.New("Conflict")
+	InternalServerError  = errors.New("Internal Server Error")
+	AlreadyExists        = errors.New("Resource already exists")
+	DatesUnavailable     = errors.New("Dates unavailable")
+	UnsupportedImage     = errors.New("Unsupported image format")
+	ImageTooLarge        = errors.New("Image dimensions are too large")
+	ImageQuotaExceeded   = errors.New("Image quota for property exceeded")
+	PreconditionFailed   = errors.New("Resource has been modified, fetch it again")
+	PreconditionRequired = errors.New("If-Match header is required")
 )
 
-var (
-	BadRequest          = errors.New("Bad Request")
-	Unauthorized        = errors.New("Unauthorized")
-	InvalidJWTToken     = errors.New("Invalid JWT token")
-	ErrNoCookie         = errors.New("No cookie")
-	InvalidJWTClaims    = errors.New("Invalid JWT claims")
-	NotFound            = errors.New("Not Found")
-	InternalServerError = errors.New("Internal Server Error")
-	ExistsEmailError    = errors.New("User with given email already exists")
+// Машиночитаемые коды ошибок; клиенты ветвятся по коду, а не по тексту сообщения
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeAlreadyExists        = "already_exists"
+	CodeDatesUnavailable     = "dates_unavailable"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedImage     = "unsupported_image"
+	CodeImageTooLarge        = "image_too_large"
+	CodeImageQuotaExceeded   = "image_quota_exceeded"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeInternal             = "internal_error"
 )
 
+// sentinelCodes - коды ошибок-значений пакета; используются в NewRestErrorFrom и ParseErrors
+var sentinelCodes = map[error]string{
+	BadRequest:           CodeBadRequest,
+	Unauthorized:         CodeUnauthorized,
+	InvalidJWTToken:      CodeUnauthorized,
+	InvalidJWTClaims:     CodeUnauthorized,
+	ErrNoCookie:          CodeUnauthorized,
+	Forbidden:            CodeForbidden,
+	NotFound:             CodeNotFound,
+	Conflict:             CodeConflict,
+	AlreadyExists:        CodeAlreadyExists,
+	DatesUnavailable:     CodeDatesUnavailable,
+	UnsupportedImage:     CodeUnsupportedImage,
+	ImageTooLarge:        CodeImageTooLarge,
+	ImageQuotaExceeded:   CodeImageQuotaExceeded,
+	PreconditionFailed:   CodePreconditionFailed,
+	PreconditionRequired: CodePreconditionRequired,
+}
+
 type RestErr interface {
 	Status() int
+	Code() string
 	Error() string
+	Message() string
+	Fields() []FieldError
 	Causes() interface{}
 }
 
+// FieldError - ошибка проверки одного поля запроса; Field - путь по JSON-именам, например property.title
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// RestError попадает к клиенту только через Body: причина (ErrCauses) может содержать
+// текст SQL и внутренние детали, поэтому наружу отдаётся лишь для 4xx и только если это не error
 type RestError struct {
-	ErrStatus int         `json:"status,omitempty"`
-	ErrError  string      `json:"error,omitempty"`
-	ErrCauses interface{} `json:"-"`
+	ErrStatus int
+	ErrCode   string
+	ErrError  string
+	ErrFields []FieldError
+	ErrCauses interface{}
+	sentinel  error
 }
 
 func (e RestError) Error() string {
@@ -43,75 +103,247 @@ func (e RestError) Status() int {
 	return e.ErrStatus
 }
 
+func (e RestError) Code() string {
+	return e.ErrCode
+}
+
+func (e RestError) Message() string {
+	return e.ErrError
+}
+
+func (e RestError) Fields() []FieldError {
+	return e.ErrFields
+}
+
 func (e RestError) Causes() interface{} {
 	return e.ErrCauses
 }
 
+// Unwrap позволяет проверять ошибку через errors.Is(err, httpErrors.DatesUnavailable)
+func (e RestError) Unwrap() error {
+	return e.sentinel
+}
+
+// Body - единый формат ответа с ошибкой
+type Body struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	Details   interface{}  `json:"details,omitempty"`
+	RequestId string       `json:"requestId,omitempty"`
+}
+
+func NewBody(err RestErr, requestId string) Body {
+	body := Body{
+		Code:      err.Code(),
+		Message:   err.Message(),
+		Fields:    err.Fields(),
+		RequestId: requestId,
+	}
+	if _, isErr := err.Causes().(error); !isErr && err.Status() < http.StatusInternalServerError {
+		body.Details = err.Causes()
+	}
+	return body
+}
+
 func NewRestError(status int, err string, causes interface{}) RestErr {
 	return RestError{
 		ErrStatus: status,
+		ErrCode:   codeForStatus(status),
 		ErrError:  err,
 		ErrCauses: causes,
 	}
 }
 
 func NewRestErrorWithMessage(status int, err string, causes interface{}) RestErr {
+	return NewRestError(status, err, causes)
+}
+
+// NewRestErrorFrom строит ошибку из значения пакета (DatesUnavailable, UnsupportedImage, ...) с его кодом
+func NewRestErrorFrom(status int, sentinel error, causes interface{}) RestErr {
+	code, ok := sentinelCodes[sentinel]
+	if !ok {
+		code = codeForStatus(status)
+	}
 	return RestError{
 		ErrStatus: status,
-		ErrError:  err,
+		ErrCode:   code,
+		ErrError:  sentinel.Error(),
 		ErrCauses: causes,
+		sentinel:  sentinel,
 	}
 }
 
 func NewInternalServerError(causes interface{}) RestErr {
-	result := RestError{
-		ErrStatus: http.StatusInternalServerError,
-		ErrError:  InternalServerError.Error(),
-		ErrCauses: causes,
-	}
-	return result
+	return NewRestErrorFrom(http.StatusInternalServerError, InternalServerError, causes)
 }
 
+// NewBadRequestError: строка в causes становится сообщением для клиента, error - только причиной в логе.
+// То же для остальных конструкторов 4xx.
 func NewBadRequestError(causes interface{}) RestErr {
-	result := RestError{
-		ErrStatus: http.StatusBadRequest,
-		ErrError:  BadRequest.Error(),
-		ErrCauses: causes,
-	}
-	return result
+	return newClientError(http.StatusBadRequest, BadRequest, causes)
 }
 
 func NewUnauthorizedError(causes interface{}) RestErr {
+	return newClientError(http.StatusUnauthorized, Unauthorized, causes)
+}
+
+func NewForbiddenError(causes interface{}) RestErr {
+	return newClientError(http.StatusForbidden, Forbidden, causes)
+}
+
+func NewConflictError(causes interface{}) RestErr {
+	return newClientError(http.StatusConflict, Conflict, causes)
+}
+
+func NewNotFoundError(causes interface{}) RestErr {
+	return newClientError(http.StatusNotFound, NotFound, causes)
+}
+
+func newClientError(status int, sentinel error, causes interface{}) RestErr {
+	restErr := NewRestErrorFrom(status, sentinel, causes).(RestError)
+	if message, ok := causes.(string); ok {
+		restErr.ErrError = message
+		restErr.ErrCauses = nil
+	}
+	return restErr
+}
+
+// NewValidationError - 400 с ошибками по полям
+func NewValidationError(fields []FieldError, causes interface{}) RestErr {
 	return RestError{
-		ErrStatus: http.StatusUnauthorized,
-		ErrError:  Unauthorized.Error(),
+		ErrStatus: http.StatusBadRequest,
+		ErrCode:   CodeValidationFailed,
+		ErrError:  "Validation failed",
+		ErrFields: fields,
 		ErrCauses: causes,
+		sentinel:  BadRequest,
 	}
 }
 
+// ParseErrors приводит любую ошибку слоёв сервиса и хранилища к RestErr
 func ParseErrors(err error) RestErr {
+	var (
+		restErr        RestErr
+		validationErrs validator.ValidationErrors
+		httpErr        *echo.HTTPError
+		pgErr          *pgconn.PgError
+		numErr         *strconv.NumError
+	)
 	switch {
+	case errors.As(err, &restErr):
+		return restErr
 	case errors.Is(err, sql.ErrNoRows):
-		return NewRestError(http.StatusNotFound, NotFound.Error(), err)
-	case strings.Contains(err.Error(), "SQLSTATE"):
-		return parseSqlErrors(err)
-	default:
-		var restErr RestErr
-		if errors.As(err, &restErr) {
-			return restErr
+		return NewRestErrorFrom(http.StatusNotFound, NotFound, err)
+	case errors.As(err, &validationErrs):
+		return NewValidationError(fieldErrors(validationErrs), err)
+	case errors.As(err, &pgErr):
+		return parseSqlErrors(pgErr)
+	case errors.As(err, &httpErr):
+		return parseEchoError(httpErr)
+	case errors.As(err, &numErr):
+		return NewRestErrorFrom(http.StatusBadRequest, BadRequest, err)
+	}
+	for sentinel := range sentinelCodes {
+		if errors.Is(err, sentinel) {
+			return NewRestErrorFrom(sentinelStatus(sentinel), sentinel, err)
 		}
-		return NewInternalServerError(err)
 	}
+	return NewInternalServerError(err)
 }
 
-func parseSqlErrors(err error) RestErr {
-	if strings.Contains(err.Error(), "23505") {
-		return NewRestError(http.StatusBadRequest, ExistsEmailError.Error(), err)
+// Коды SQLSTATE Postgres, которые различает API
+const (
+	pgExclusionViolation        = "23P01"
+	pgUniqueViolation           = "23505"
+	pgForeignKeyViolation       = "23503"
+	pgNotNullViolation          = "23502"
+	pgCheckViolation            = "23514"
+	pgInvalidTextRepresentation = "22P02"
+	pgStringDataRightTruncation = "22001"
+	pgNumericValueOutOfRange    = "22003"
+	pgInvalidDatetimeFormat     = "22007"
+	pgDatetimeFieldOverflow     = "22008"
+	pgSerializationFailure      = "40001"
+	pgDeadlockDetected          = "40P01"
+	pgLockNotAvailable          = "55P03"
+)
+
+// parseSqlErrors сопоставляет коды SQLSTATE Postgres с ответами API
+func parseSqlErrors(err *pgconn.PgError) RestErr {
+	switch err.Code {
+	case pgExclusionViolation:
+		// пересечение диапазонов дат бронирований
+		return NewRestErrorFrom(http.StatusConflict, DatesUnavailable, err)
+	case pgUniqueViolation:
+		return NewRestErrorFrom(http.StatusConflict, AlreadyExists, err)
+	case pgForeignKeyViolation:
+		return NewRestError(http.StatusConflict, "Referenced resource does not exist or is still in use", err)
+	case pgNotNullViolation, pgCheckViolation, pgInvalidTextRepresentation,
+		pgStringDataRightTruncation, pgNumericValueOutOfRange,
+		pgInvalidDatetimeFormat, pgDatetimeFieldOverflow:
+		return NewRestErrorFrom(http.StatusBadRequest, BadRequest, err)
+	case pgSerializationFailure, pgDeadlockDetected, pgLockNotAvailable:
+		return NewRestError(http.StatusConflict, "Concurrent update, retry the request", err)
 	}
+	return NewInternalServerError(err)
+}
 
-	return NewRestError(http.StatusBadRequest, BadRequest.Error(), err)
+func parseEchoError(err *echo.HTTPError) RestErr {
+	message, ok := err.Message.(string)
+	if !ok {
+		message = http.StatusText(err.Code)
+	}
+	causes := err.Internal
+	if causes == nil {
+		causes = err
+	}
+	return RestError{
+		ErrStatus: err.Code,
+		ErrCode:   codeForStatus(err.Code),
+		ErrError:  message,
+		ErrCauses: causes,
+	}
 }
 
-func ErrorResponse(err error) (int, interface{}) {
-	return ParseErrors(err).Status(), ParseErrors(err)
+func sentinelStatus(sentinel error) int {
+	switch sentinel {
+	case Unauthorized, InvalidJWTToken, InvalidJWTClaims, ErrNoCookie:
+		return http.StatusUnauthorized
+	case Forbidden:
+		return http.StatusForbidden
+	case NotFound:
+		return http.StatusNotFound
+	case Conflict, AlreadyExists, DatesUnavailable:
+		return http.StatusConflict
+	case ImageTooLarge, ImageQuotaExceeded:
+		return http.StatusRequestEntityTooLarge
+	case PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case PreconditionRequired:
+		return http.StatusPreconditionRequired
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
 }
@@ -0,0 +1,50 @@
+package httpErrors
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"strings"
+)
+
+// fieldErrors переводит ошибки validator в сообщения по полям. Имена полей - JSON-теги
+// (их регистрирует utils), первый сегмент пути - имя структуры запроса - отбрасывается.
+func fieldErrors(errs validator.ValidationErrors) []FieldError {
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		field := fe.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+		fields = append(fields, FieldError{Field: field, Message: fieldMessage(fe)})
+	}
+	return fields
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", fe.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt", "gtfield":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte", "gtefield":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "lt", "ltfield":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "lte", "ltefield":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "unique":
+		return "must not contain duplicates"
+	case "latitude", "longitude", "email", "url", "datetime":
+		return fmt.Sprintf("must be a valid %s", fe.Tag())
+	default:
+		return fmt.Sprintf("failed on %s validation", fe.Tag())
+	}
+}
@@ -0,0 +1,393 @@
+// Package ical реализует минимальное подмножество RFC 5545, достаточное
+// для обмена занятостью объектов: VCALENDAR с компонентами VEVENT.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+	utcLayout      = "20060102T150405Z"
+
+	maxLineOctets = 75
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar document")
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Event - событие календаря. Для событий на весь день (AllDay) End не входит в интервал,
+// как и DTEND;VALUE=DATE в RFC 5545.
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+	Status  string
+}
+
+// Days возвращает даты (в формате 2006-01-02), которые занимает событие в полуинтервале дат [from, to).
+// Окно обязательно: событие из внешнего календаря может тянуться на тысячи лет.
+func (e Event) Days(from, to time.Time) []string {
+	start := truncateDay(e.Start)
+	end := truncateDay(e.End)
+	if !e.AllDay && !e.End.Equal(time.Date(e.End.Year(), e.End.Month(), e.End.Day(), 0, 0, 0, 0, e.End.Location())) {
+		// Событие со временем захватывает и день окончания, если заканчивается не в полночь
+		end = end.AddDate(0, 0, 1)
+	}
+	if from = truncateDay(from); start.Before(from) {
+		start = from
+	}
+	if to = truncateDay(to); end.After(to) {
+		end = to
+	}
+
+	days := []string{}
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format("2006-01-02"))
+	}
+	return days
+}
+
+// truncateDay возвращает полночь UTC календарного дня t
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Encode записывает календарь в w в формате text/calendar
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(utcLayout)
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + escapeText(c.ProdID),
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+	if c.Name != "" {
+		lines = append(lines, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+	for _, event := range c.Events {
+		lines = append(lines, "BEGIN:VEVENT", "UID:"+escapeText(event.UID), "DTSTAMP:"+stamp)
+		if event.AllDay {
+			lines = append(lines,
+				"DTSTART;VALUE=DATE:"+event.Start.Format(dateLayout),
+				"DTEND;VALUE=DATE:"+event.End.Format(dateLayout))
+		} else {
+			lines = append(lines,
+				"DTSTART:"+event.Start.UTC().Format(utcLayout),
+				"DTEND:"+event.End.UTC().Format(utcLayout))
+		}
+		if event.Summary != "" {
+			lines = append(lines, "SUMMARY:"+escapeText(event.Summary))
+		}
+		if event.Status != "" {
+			lines = append(lines, "STATUS:"+event.Status)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := bw.WriteString(fold(line)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Parse читает документ iCalendar и возвращает его события VEVENT
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events    []Event
+		current   *Event
+		props     map[string]property
+		depth     int
+		sawHeader bool
+		sawFooter bool
+	)
+
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCalendar, i+1, err)
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			if strings.EqualFold(prop.value, "VCALENDAR") {
+				sawHeader = true
+			}
+			if current != nil {
+				depth++
+			} else if strings.EqualFold(prop.value, "VEVENT") {
+				current = &Event{}
+				props = make(map[string]property)
+			}
+			continue
+		case "END":
+			if current == nil {
+				if strings.EqualFold(prop.value, "VCALENDAR") {
+					sawFooter = true
+				}
+				continue
+			}
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if strings.EqualFold(prop.value, "VEVENT") {
+				if err := buildEvent(current, props); err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+				}
+				events = append(events, *current)
+				current = nil
+			}
+			continue
+		}
+
+		// Свойства вложенных компонентов (например, VALARM) пропускаем
+		if current != nil && depth == 0 {
+			props[prop.name] = prop
+		}
+	}
+
+	if !sawHeader {
+		return nil, fmt.Errorf("%w: missing BEGIN:VCALENDAR", ErrInvalidCalendar)
+	}
+	if current != nil {
+		return nil, fmt.Errorf("%w: unterminated VEVENT", ErrInvalidCalendar)
+	}
+	// Без END:VCALENDAR документ мог быть обрезан между событиями
+	if !sawFooter {
+		return nil, fmt.Errorf("%w: missing END:VCALENDAR", ErrInvalidCalendar)
+	}
+	return events, nil
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func buildEvent(event *Event, props map[string]property) error {
+	start, ok := props["DTSTART"]
+	if !ok {
+		return errors.New("VEVENT without DTSTART")
+	}
+
+	var err error
+	event.Start, event.AllDay, err = parseTime(start)
+	if err != nil {
+		return fmt.Errorf("DTSTART: %w", err)
+	}
+
+	if end, ok := props["DTEND"]; ok {
+		event.End, _, err = parseTime(end)
+		if err != nil {
+			return fmt.Errorf("DTEND: %w", err)
+		}
+	} else if duration, ok := props["DURATION"]; ok {
+		d, err := parseDuration(duration.value)
+		if err != nil {
+			return fmt.Errorf("DURATION: %w", err)
+		}
+		event.End = event.Start.Add(d)
+	} else if event.AllDay {
+		event.End = event.Start.AddDate(0, 0, 1)
+	} else {
+		event.End = event.Start
+	}
+
+	if event.End.Before(event.Start) {
+		return errors.New("DTEND is before DTSTART")
+	}
+
+	event.UID = unescapeText(props["UID"].value)
+	event.Summary = unescapeText(props["SUMMARY"].value)
+	event.Status = strings.ToUpper(props["STATUS"].value)
+	return nil
+}
+
+func parseTime(prop property) (time.Time, bool, error) {
+	value := prop.value
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, time.UTC)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+
+	loc := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	return t, false, err
+}
+
+// parseDuration разбирает значение DURATION вида P1W, P2D, PT3H30M, P1DT12H
+func parseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	inTime := false
+	number := 0
+	hasNumber := false
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number = number*10 + int(r-'0')
+			hasNumber = true
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		if !hasNumber {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		n := time.Duration(number)
+		switch {
+		case r == 'W' && !inTime:
+			total += n * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			total += n * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += n * time.Hour
+		case r == 'M' && inTime:
+			total += n * time.Minute
+		case r == 'S' && inTime:
+			total += n * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number, hasNumber = 0, false
+	}
+	if hasNumber {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * total, nil
+}
+
+// unfold читает строки документа, склеивая перенесённые (RFC 5545, 3.1)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func parseLine(line string) (property, error) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return property{}, fmt.Errorf("malformed content line %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+// fold разбивает строку на части не длиннее 75 октетов, не разрывая символы UTF-8
+func fold(line string) string {
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Пробел в начале продолжения тоже считается
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
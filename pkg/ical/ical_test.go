@@ -0,0 +1,275 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, name string) []Event {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	events, err := Parse(f)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return events
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseFolding(t *testing.T) {
+	events := parseFixture(t, "folded.ics")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+
+	want := "Очень длинное описание бронирования, которое календарь перенёс на несколько строк; продолжение с табуляцией и ещё одно"
+	if events[0].Summary != want {
+		t.Errorf("Summary = %q, want %q", events[0].Summary, want)
+	}
+	if events[0].UID != "folded-1@example.com" {
+		t.Errorf("UID = %q", events[0].UID)
+	}
+}
+
+func TestParseTZID(t *testing.T) {
+	events := parseFixture(t, "tzid.ics")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+
+	event := events[0]
+	if event.AllDay {
+		t.Error("AllDay = true, want false")
+	}
+	// 01:00 по Москве - ещё 22:00 предыдущего дня по UTC
+	if want := time.Date(2030, 2, 28, 22, 0, 0, 0, time.UTC); !event.Start.Equal(want) {
+		t.Errorf("Start = %v, want %v", event.Start.UTC(), want)
+	}
+	if want := time.Date(2030, 3, 1, 21, 0, 0, 0, time.UTC); !event.End.Equal(want) {
+		t.Errorf("End = %v, want %v", event.End.UTC(), want)
+	}
+	// Дни считаются по местному времени события, а не по UTC
+	got := event.Days(date(2030, 1, 1), date(2031, 1, 1))
+	if want := []string{"2030-03-01"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Days = %v, want %v", got, want)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	events := parseFixture(t, "duration.ics")
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	week := events[0]
+	if !week.AllDay || !week.End.Equal(date(2030, 4, 8)) {
+		t.Errorf("week event: AllDay = %v, End = %v", week.AllDay, week.End)
+	}
+
+	// DURATION вложенного VALARM не должен перекрыть DURATION события
+	timed := events[1]
+	if want := time.Date(2030, 4, 12, 1, 30, 0, 0, time.UTC); !timed.End.Equal(want) {
+		t.Errorf("timed event End = %v, want %v", timed.End, want)
+	}
+	got := timed.Days(date(2030, 1, 1), date(2031, 1, 1))
+	if want := []string{"2030-04-10", "2030-04-11", "2030-04-12"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Days = %v, want %v", got, want)
+	}
+}
+
+func TestParseDurationValues(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "P2D", want: 48 * time.Hour},
+		{value: "PT3H30M", want: 3*time.Hour + 30*time.Minute},
+		{value: "P1DT12H", want: 36 * time.Hour},
+		{value: "+PT15S", want: 15 * time.Second},
+		{value: "-P1W", want: -7 * 24 * time.Hour},
+		{value: "P", wantErr: true},
+		{value: "PT", wantErr: true},
+		{value: "P1H", wantErr: true},
+		{value: "PT1D", wantErr: true},
+		{value: "P1", wantErr: true},
+		{value: "1D", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDuration(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAllDay(t *testing.T) {
+	events := parseFixture(t, "allday.ics")
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	window := [2]time.Time{date(2030, 1, 1), date(2031, 1, 1)}
+	tests := []struct {
+		event  Event
+		status string
+		days   []string
+	}{
+		// DTEND;VALUE=DATE не входит в событие
+		{event: events[0], status: "CONFIRMED", days: []string{"2030-05-01", "2030-05-02", "2030-05-03"}},
+		// Без DTEND событие на весь день длится один день
+		{event: events[1], days: []string{"2030-05-10"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event.UID, func(t *testing.T) {
+			if !tt.event.AllDay {
+				t.Error("AllDay = false, want true")
+			}
+			if tt.event.Status != tt.status {
+				t.Errorf("Status = %q, want %q", tt.event.Status, tt.status)
+			}
+			if got := tt.event.Days(window[0], window[1]); !reflect.DeepEqual(got, tt.days) {
+				t.Errorf("Days = %v, want %v", got, tt.days)
+			}
+		})
+	}
+}
+
+func TestParseCancelled(t *testing.T) {
+	events := parseFixture(t, "cancelled.ics")
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Status != "CONFIRMED" || events[1].Status != "CANCELLED" {
+		t.Errorf("statuses = %q, %q", events[0].Status, events[1].Status)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no calendar":     "BEGIN:VEVENT\nDTSTART:20300101T000000Z\nEND:VEVENT\n",
+		"no dtstart":      "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\nEND:VEVENT\nEND:VCALENDAR\n",
+		"end before":      "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20300105\nDTEND;VALUE=DATE:20300101\nEND:VEVENT\nEND:VCALENDAR\n",
+		"unterminated":    "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20300105\n",
+		"malformed line":  "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART\nEND:VEVENT\nEND:VCALENDAR\n",
+		"bad date":        "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2030-01-01\nEND:VEVENT\nEND:VCALENDAR\n",
+		"bad duration":    "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20300105\nDURATION:P1X\nEND:VEVENT\nEND:VCALENDAR\n",
+		"negative length": "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20300105\nDURATION:-P1D\nEND:VEVENT\nEND:VCALENDAR\n",
+		// Документ, обрезанный между событиями, не принимается за полный
+		"truncated": "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20300105\nEND:VEVENT\n",
+	}
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(doc))
+			if !errors.Is(err, ErrInvalidCalendar) {
+				t.Errorf("err = %v, want ErrInvalidCalendar", err)
+			}
+		})
+	}
+}
+
+func TestDaysWindow(t *testing.T) {
+	events := parseFixture(t, "huge.ics")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+
+	// Событие на 10 000 лет сводится к окну, а не перебирается целиком
+	got := events[0].Days(time.Date(2030, 1, 30, 15, 0, 0, 0, time.UTC), date(2030, 2, 2))
+	if want := []string{"2030-01-30", "2030-01-31", "2030-02-01"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Days = %v, want %v", got, want)
+	}
+
+	// Событие вне окна не занимает ни одного дня
+	got = events[0].Days(date(2030, 2, 2), date(2030, 2, 2))
+	if len(got) != 0 {
+		t.Errorf("Days with empty window = %v, want none", got)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	summary := strings.Repeat("Бронирование; гость, ", 5) + `путь C:\tmp`
+	calendar := &Calendar{
+		ProdID: "-//Test//RU",
+		Name:   "Квартира у моря",
+		Events: []Event{
+			{UID: "all-day@test", Summary: summary, Start: date(2030, 7, 1), End: date(2030, 7, 5), AllDay: true, Status: "CONFIRMED"},
+			{UID: "timed@test", Start: time.Date(2030, 7, 10, 12, 0, 0, 0, time.UTC), End: time.Date(2030, 7, 11, 9, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line is %d octets long: %q", len(line), line)
+		}
+	}
+
+	events, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for i, want := range calendar.Events {
+		got := events[i]
+		if got.UID != want.UID || got.Summary != want.Summary || got.AllDay != want.AllDay || got.Status != want.Status ||
+			!got.Start.Equal(want.Start) || !got.End.Equal(want.End) {
+			t.Errorf("event %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	// Многобайтовые символы не должны разрываться на границе переноса
+	line := "SUMMARY:" + strings.Repeat("ж", 100)
+	folded := fold(line)
+
+	if !strings.HasSuffix(folded, "\r\n") {
+		t.Fatalf("folded line must end with CRLF: %q", folded)
+	}
+	parts := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	if len(parts) < 2 {
+		t.Fatalf("line was not folded: %q", folded)
+	}
+	for i, part := range parts {
+		if len(part) > maxLineOctets {
+			t.Errorf("part %d is %d octets long", i, len(part))
+		}
+		if i > 0 && !strings.HasPrefix(part, " ") {
+			t.Errorf("part %d does not start with a space", i)
+		}
+	}
+
+	lines, err := unfold(strings.NewReader(folded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0] != line {
+		t.Errorf("unfold(fold(line)) = %q, want %q", lines, line)
+	}
+}
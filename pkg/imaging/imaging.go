@@ -0,0 +1,100 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+const (
+	SizeThumb  = "thumb"
+	SizeMedium = "medium"
+	SizeLarge  = "large"
+
+	ContentTypeJPEG = "image/jpeg"
+
+	jpegQuality = 85
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Variant описывает копию изображения: MaxSide - ограничение на длинную сторону в пикселях
+// (0 - без уменьшения), Quality - качество JPEG (0 - по умолчанию)
+type Variant struct {
+	Name    string
+	MaxSide int
+	Quality int
+}
+
+// Original - копия исходного изображения в полном размере. Хранится вместо загруженного файла,
+// чтобы по ссылке на исходник не раздавались EXIF с координатами съёмки и другие метаданные.
+var Original = Variant{Name: "original", Quality: 92}
+
+var DefaultVariants = []Variant{
+	{Name: SizeThumb, MaxSide: 320},
+	{Name: SizeMedium, MaxSide: 800},
+	{Name: SizeLarge, MaxSide: 1600},
+}
+
+type Result struct {
+	Name        string
+	Data        []byte
+	Width       int
+	Height      int
+	ContentType string
+}
+
+// Process декодирует изображение, поворачивает его по EXIF-ориентации и кодирует каждый вариант в JPEG.
+// Перекодирование отбрасывает все метаданные исходного файла, включая EXIF.
+func Process(data []byte, variants []Variant) ([]Result, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	src = orient(src, exifOrientation(data))
+
+	results := make([]Result, 0, len(variants))
+	for _, variant := range variants {
+		dst := resize(src, variant.MaxSide)
+
+		quality := variant.Quality
+		if quality == 0 {
+			quality = jpegQuality
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("encode %s: %w", variant.Name, err)
+		}
+		results = append(results, Result{
+			Name:        variant.Name,
+			Data:        buf.Bytes(),
+			Width:       dst.Bounds().Dx(),
+			Height:      dst.Bounds().Dy(),
+			ContentType: ContentTypeJPEG,
+		})
+	}
+	return results, nil
+}
+
+// resize уменьшает изображение до maxSide по длинной стороне, не увеличивая маленькие; maxSide 0 - без уменьшения.
+// Прозрачные области заливаются белым, так как JPEG не поддерживает альфа-канал.
+func resize(src image.Image, maxSide int) *image.RGBA {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if longest := max(width, height); maxSide > 0 && longest > maxSide {
+		width = max(1, width*maxSide/longest)
+		height = max(1, height*maxSide/longest)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+	return dst
+}
@@ -0,0 +1,121 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// gpsMarker - строка внутри EXIF, по которой проверяется, что метаданные не попали в результат
+const gpsMarker = "GPS 55.7558N 37.6173E"
+
+// jpegWithExif кодирует изображение width x height и вставляет после SOI блок APP1 с EXIF:
+// тег Orientation и произвольный текст, имитирующий координаты съёмки
+func jpegWithExif(t *testing.T, width, height, orientation int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// TIFF: заголовок, одна запись IFD (Orientation) и следом текст
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, gpsMarker...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestExifOrientation(t *testing.T) {
+	for orientation := 1; orientation <= 8; orientation++ {
+		if got := exifOrientation(jpegWithExif(t, 4, 2, orientation)); got != orientation {
+			t.Errorf("exifOrientation = %d, want %d", got, orientation)
+		}
+	}
+	if got := exifOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("exifOrientation(garbage) = %d, want 1", got)
+	}
+}
+
+func TestProcessOriginalStripsMetadata(t *testing.T) {
+	data := jpegWithExif(t, 400, 200, 6)
+	if !bytes.Contains(data, []byte(gpsMarker)) {
+		t.Fatal("fixture has no EXIF")
+	}
+
+	results, err := Process(data, append([]Variant{Original}, DefaultVariants...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(DefaultVariants)+1 {
+		t.Fatalf("got %d results, want %d", len(results), len(DefaultVariants)+1)
+	}
+
+	for _, result := range results {
+		if bytes.Contains(result.Data, []byte(gpsMarker)) || bytes.Contains(result.Data, []byte("Exif\x00\x00")) {
+			t.Errorf("%s keeps EXIF", result.Name)
+		}
+		if result.ContentType != ContentTypeJPEG {
+			t.Errorf("%s content type = %s", result.Name, result.ContentType)
+		}
+	}
+
+	// Исходник не уменьшается, но поворачивается по EXIF: 400x200 с ориентацией 6 становится 200x400
+	original := results[0]
+	if original.Name != Original.Name || original.Width != 200 || original.Height != 400 {
+		t.Errorf("original = %s %dx%d, want %s 200x400", original.Name, original.Width, original.Height, Original.Name)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(original.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 200 || cfg.Height != 400 {
+		t.Errorf("encoded original is %dx%d, want 200x400", cfg.Width, cfg.Height)
+	}
+}
+
+func TestProcessVariantSizes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 2000, 1000))); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Process(buf.Bytes(), []Variant{Original, {Name: SizeThumb, MaxSide: 320}, {Name: "tiny", MaxSide: 5000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]int{{2000, 1000}, {320, 160}, {2000, 1000}}
+	for i, result := range results {
+		if result.Width != want[i][0] || result.Height != want[i][1] {
+			t.Errorf("%s = %dx%d, want %dx%d", result.Name, result.Width, result.Height, want[i][0], want[i][1])
+		}
+	}
+}
+
+func TestProcessRejectsGarbage(t *testing.T) {
+	if _, err := Process([]byte("definitely not an image"), DefaultVariants); err == nil {
+		t.Error("Process succeeded, want error")
+	}
+}
@@ -0,0 +1,49 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+)
+
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// formats - поддерживаемые форматы по MIME-типу, определённому по сигнатуре файла
+var formats = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type Info struct {
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// Inspect определяет формат по сигнатуре и читает размеры из заголовка, не декодируя пиксели.
+// Изображения больше maxPixels отклоняются, чтобы сжатый файл не раздулся в памяти при декодировании.
+func Inspect(data []byte, maxPixels int64) (*Info, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := formats[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("%w: empty image", ErrUnsupportedFormat)
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, cfg.Width, cfg.Height)
+	}
+
+	return &Info{ContentType: contentType, Ext: ext, Width: cfg.Width, Height: cfg.Height}, nil
+}
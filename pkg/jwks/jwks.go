@@ -0,0 +1,317 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("jwks: key not found")
+
+// minRefreshInterval ограничивает частоту внеплановых перечитываний набора по неизвестному kid,
+// чтобы токены с мусорным kid не превращались в поток запросов к источнику
+const minRefreshInterval = 30 * time.Second
+
+// Key - открытый ключ проверки подписи из набора
+type Key struct {
+	Id string
+	// Alg пуст, если ключ не привязан к конкретному алгоритму
+	Alg       string
+	PublicKey crypto.PublicKey
+}
+
+// Source возвращает JSON с набором ключей (RFC 7517)
+type Source func(ctx context.Context) ([]byte, error)
+
+// FileSource читает набор ключей из локального файла
+func FileSource(path string) Source {
+	return func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// URLSource загружает набор ключей по HTTP
+func URLSource(url string, client *http.Client) Source {
+	return func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		// Набор ключей не бывает большим, ограничиваем на случай ошибки на стороне источника
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}
+}
+
+// KeySet кэширует ключи источника. Набор перечитывается по истечении ttl, а также
+// при запросе неизвестного kid - так подхватываются новые ключи при ротации.
+// Источник опрашивается не чаще minRefreshInterval и вне блокировки: пока он недоступен,
+// запросы обслуживаются из кэша и не выстраиваются в очередь за зависшим перечитыванием.
+type KeySet struct {
+	source Source
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]Key
+	fetchedAt   time.Time
+	refreshedAt time.Time
+	// lastErr - ошибка последнего перечитывания; отдаётся, пока в кэше нет ни одного набора
+	lastErr error
+	// refreshing закрывается по завершении текущего перечитывания; nil, если оно не идёт
+	refreshing chan struct{}
+}
+
+func NewKeySet(source Source, ttl time.Duration) *KeySet {
+	return &KeySet{source: source, ttl: ttl, now: time.Now}
+}
+
+// Key возвращает ключ по kid. Пустой kid допустим, только если в наборе ровно один ключ.
+func (s *KeySet) Key(ctx context.Context, kid string) (*Key, error) {
+	const op = "jwks.KeySet.Key"
+	s.mu.Lock()
+
+	now := s.now()
+	if s.keys == nil || now.Sub(s.fetchedAt) > s.ttl {
+		// Устаревший набор обновляется в фоне, ждать приходится только самого первого
+		done := s.startRefresh(ctx, now)
+		if s.keys == nil && done != nil {
+			s.mu.Unlock()
+			if err := wait(ctx, done); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			s.mu.Lock()
+		}
+		if s.keys == nil {
+			err := s.lastErr
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if key, ok := s.lookup(kid); ok {
+		s.mu.Unlock()
+		return key, nil
+	}
+
+	done := s.startRefresh(ctx, now)
+	s.mu.Unlock()
+	if done == nil {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrKeyNotFound, kid)
+	}
+	if err := wait(ctx, done); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: %w: %q", op, ErrKeyNotFound, kid)
+}
+
+func (s *KeySet) lookup(kid string) (*Key, bool) {
+	if kid == "" {
+		if len(s.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range s.keys {
+			return &key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return &key, ok
+}
+
+// startRefresh запускает перечитывание, если оно ещё не идёт и прошло не меньше minRefreshInterval
+// с прошлой попытки. Возвращает канал завершения или nil, если перечитывание сейчас запрещено.
+// Вызывается под s.mu.
+func (s *KeySet) startRefresh(ctx context.Context, now time.Time) chan struct{} {
+	if s.refreshing != nil {
+		return s.refreshing
+	}
+	if !s.refreshedAt.IsZero() && now.Sub(s.refreshedAt) < minRefreshInterval {
+		return nil
+	}
+	s.refreshedAt = now
+	s.refreshing = make(chan struct{})
+	// Перечитывание нужно всем ожидающим, поэтому отмена запроса, который его начал, не должна его прерывать
+	go s.refresh(context.WithoutCancel(ctx), s.refreshing)
+	return s.refreshing
+}
+
+// refresh перечитывает набор. При ошибке прежние ключи остаются в кэше:
+// недоступность источника не должна сразу отключать всех пользователей.
+func (s *KeySet) refresh(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	data, err := s.source(ctx)
+	var keys map[string]Key
+	if err == nil {
+		keys, err = Parse(data)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshing = nil
+	s.lastErr = err
+	if err == nil {
+		s.keys = keys
+		s.fetchedAt = s.now()
+	}
+}
+
+func wait(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Verifies проверяет, что ключом можно проверить подпись алгоритма alg:
+// совпадают alg ключа (если задан), тип ключа и кривая EC.
+func (k *Key) Verifies(alg string) error {
+	if k.Alg != "" && k.Alg != alg {
+		return fmt.Errorf("key %q is for %s, not %s", k.Id, k.Alg, alg)
+	}
+
+	switch publicKey := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		switch alg {
+		case "RS256", "RS384", "RS512":
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if curve, ok := ecCurves[alg]; ok && publicKey.Curve == curve {
+			return nil
+		}
+	}
+	return fmt.Errorf("key %q cannot verify %s", k.Id, alg)
+}
+
+var ecCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Parse разбирает набор ключей. Поддерживаются RSA и EC (P-256, P-384, P-521);
+// ключи шифрования и ключи других типов пропускаются.
+func Parse(data []byte) (map[string]Key, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var (
+			publicKey crypto.PublicKey
+			err       error
+		)
+		switch jwk.Kty {
+		case "RSA":
+			publicKey, err = rsaKey(jwk)
+		case "EC":
+			publicKey, err = ecKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = Key{Id: jwk.Kid, Alg: jwk.Alg, PublicKey: publicKey}
+	}
+	return keys, nil
+}
+
+func rsaKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := decodeInt(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("n: %w", err)
+	}
+	e, err := decodeInt(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("e: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("e: out of range")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func ecKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+	x, err := decodeInt(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := decodeInt(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("missing value")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
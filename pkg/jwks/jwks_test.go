@@ -0,0 +1,384 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errSourceDown = errors.New("source is down")
+
+func encodeInt(v *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(v.Bytes())
+}
+
+func rsaJWK(t *testing.T, kid, alg string) jsonWebKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jsonWebKey{
+		Kty: "RSA", Kid: kid, Use: "sig", Alg: alg,
+		N: encodeInt(key.N), E: encodeInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(t *testing.T, kid string, curve elliptic.Curve, crv string) jsonWebKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jsonWebKey{Kty: "EC", Kid: kid, Crv: crv, X: encodeInt(key.X), Y: encodeInt(key.Y)}
+}
+
+func encodeSet(t *testing.T, keys ...jsonWebKey) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string][]jsonWebKey{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// fakeSource отдаёт текущий набор или ошибку и считает обращения
+type fakeSource struct {
+	mu    sync.Mutex
+	data  []byte
+	err   error
+	calls atomic.Int64
+}
+
+func (f *fakeSource) set(data []byte, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data, f.err = data, err
+}
+
+func (f *fakeSource) fetch(context.Context) ([]byte, error) {
+	f.calls.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.data, f.err
+}
+
+// fakeClock - управляемое время для KeySet
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestKeySet(source *fakeSource, ttl time.Duration) (*KeySet, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	set := NewKeySet(source.fetch, ttl)
+	set.now = clock.Now
+	return set, clock
+}
+
+// waitRefresh дожидается фонового перечитывания, если оно идёт
+func waitRefresh(set *KeySet) {
+	set.mu.Lock()
+	done := set.refreshing
+	set.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, newKey := rsaJWK(t, "old", "RS256"), rsaJWK(t, "new", "RS256")
+	source := &fakeSource{data: encodeSet(t, oldKey)}
+	set, clock := newTestKeySet(source, time.Hour)
+	ctx := context.Background()
+
+	if _, err := set.Key(ctx, "old"); err != nil {
+		t.Fatalf("old key: %v", err)
+	}
+
+	// Источник выпустил новый ключ: неизвестный kid перечитывает набор сразу после minRefreshInterval
+	source.set(encodeSet(t, oldKey, newKey), nil)
+	clock.Advance(minRefreshInterval)
+	key, err := set.Key(ctx, "new")
+	if err != nil {
+		t.Fatalf("new key after rotation: %v", err)
+	}
+	if key.Id != "new" || key.Alg != "RS256" {
+		t.Errorf("key = %+v", key)
+	}
+	if calls := source.calls.Load(); calls != 2 {
+		t.Errorf("source calls = %d, want 2", calls)
+	}
+
+	// Старый ключ отозван: после ttl набор перечитывается в фоне
+	source.set(encodeSet(t, newKey), nil)
+	clock.Advance(time.Hour + time.Second)
+	if _, err := set.Key(ctx, "new"); err != nil {
+		t.Fatalf("new key after ttl: %v", err)
+	}
+	waitRefresh(set)
+	if _, err := set.Key(ctx, "old"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("revoked key: err = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestKeySetUnknownKid(t *testing.T) {
+	source := &fakeSource{data: encodeSet(t, rsaJWK(t, "a", ""), rsaJWK(t, "b", ""))}
+	set, clock := newTestKeySet(source, time.Hour)
+	ctx := context.Background()
+
+	if _, err := set.Key(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Поток токенов с мусорным kid не должен превращаться в поток запросов к источнику
+	for i := 0; i < 10; i++ {
+		if _, err := set.Key(ctx, "garbage"); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("err = %v, want ErrKeyNotFound", err)
+		}
+	}
+	if calls := source.calls.Load(); calls != 1 {
+		t.Errorf("source calls = %d, want 1", calls)
+	}
+
+	clock.Advance(minRefreshInterval)
+	if _, err := set.Key(ctx, "garbage"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("err = %v, want ErrKeyNotFound", err)
+	}
+	if calls := source.calls.Load(); calls != 2 {
+		t.Errorf("source calls = %d, want 2", calls)
+	}
+
+	// Пустой kid неоднозначен, когда ключей несколько
+	if _, err := set.Key(ctx, ""); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("empty kid: err = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestKeySetEmptyKidSingleKey(t *testing.T) {
+	source := &fakeSource{data: encodeSet(t, rsaJWK(t, "only", ""))}
+	set, _ := newTestKeySet(source, time.Hour)
+
+	key, err := set.Key(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Id != "only" {
+		t.Errorf("key id = %q, want only", key.Id)
+	}
+}
+
+func TestKeySetFailedRefreshKeepsCache(t *testing.T) {
+	source := &fakeSource{data: encodeSet(t, rsaJWK(t, "a", "RS256"))}
+	set, clock := newTestKeySet(source, time.Minute)
+	ctx := context.Background()
+
+	if _, err := set.Key(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	source.set(nil, errSourceDown)
+	for i := 0; i < 5; i++ {
+		clock.Advance(2 * time.Minute)
+		if _, err := set.Key(ctx, "a"); err != nil {
+			t.Fatalf("cached key with source down: %v", err)
+		}
+		waitRefresh(set)
+	}
+	calls := source.calls.Load()
+	if calls != 6 {
+		t.Errorf("source calls = %d, want 6", calls)
+	}
+
+	// Пока источник лежит, запросы в пределах minRefreshInterval не ходят к нему повторно
+	for i := 0; i < 10; i++ {
+		if _, err := set.Key(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := set.Key(ctx, "unknown"); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("err = %v, want ErrKeyNotFound", err)
+		}
+	}
+	if got := source.calls.Load(); got != calls {
+		t.Errorf("source calls = %d, want %d", got, calls)
+	}
+
+	// Невалидный ответ источника тоже не затирает кэш
+	source.set([]byte("not json"), nil)
+	clock.Advance(2 * time.Minute)
+	if _, err := set.Key(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	waitRefresh(set)
+	if _, err := set.Key(ctx, "a"); err != nil {
+		t.Errorf("cached key after invalid response: %v", err)
+	}
+}
+
+func TestKeySetSourceDownAtStart(t *testing.T) {
+	source := &fakeSource{err: errSourceDown}
+	set, clock := newTestKeySet(source, time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if _, err := set.Key(ctx, "a"); !errors.Is(err, errSourceDown) {
+			t.Fatalf("err = %v, want errSourceDown", err)
+		}
+	}
+	if calls := source.calls.Load(); calls != 1 {
+		t.Errorf("source calls = %d, want 1", calls)
+	}
+
+	source.set(encodeSet(t, rsaJWK(t, "a", "")), nil)
+	clock.Advance(minRefreshInterval)
+	if _, err := set.Key(ctx, "a"); err != nil {
+		t.Errorf("key after source recovered: %v", err)
+	}
+}
+
+func TestKeySetSlowSourceDoesNotBlockCachedKeys(t *testing.T) {
+	key := rsaJWK(t, "a", "")
+	release := make(chan struct{})
+	var calls atomic.Int64
+	source := func(ctx context.Context) ([]byte, error) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		return encodeSet(t, key), nil
+	}
+	set := NewKeySet(source, time.Minute)
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	set.now = clock.Now
+	ctx := context.Background()
+
+	if _, err := set.Key(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Набор устарел, источник завис: известный ключ отдаётся из кэша без ожидания
+	clock.Advance(2 * time.Minute)
+	done := make(chan error, 1)
+	go func() {
+		_, err := set.Key(ctx, "a")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Key is blocked by a slow refresh")
+	}
+
+	// Запрос неизвестного kid ждёт перечитывания, но не дольше своего контекста
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := set.Key(timeoutCtx, "unknown"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	waitRefresh(set)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("source calls = %d, want 2", n)
+	}
+}
+
+func TestKeyVerifies(t *testing.T) {
+	set, err := Parse(encodeSet(t,
+		rsaJWK(t, "rsa", ""),
+		rsaJWK(t, "rsa-rs256", "RS256"),
+		ecJWK(t, "p256", elliptic.P256(), "P-256"),
+		ecJWK(t, "p384", elliptic.P384(), "P-384"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kid     string
+		alg     string
+		wantErr bool
+	}{
+		{kid: "rsa", alg: "RS256"},
+		{kid: "rsa", alg: "RS512"},
+		{kid: "rsa-rs256", alg: "RS256"},
+		{kid: "p256", alg: "ES256"},
+		{kid: "p384", alg: "ES384"},
+		// alg ключа не совпадает с алгоритмом токена
+		{kid: "rsa-rs256", alg: "RS384", wantErr: true},
+		// Тип ключа не подходит алгоритму
+		{kid: "rsa", alg: "ES256", wantErr: true},
+		{kid: "p256", alg: "RS256", wantErr: true},
+		{kid: "rsa", alg: "HS256", wantErr: true},
+		// Кривая не соответствует алгоритму
+		{kid: "p384", alg: "ES256", wantErr: true},
+		{kid: "p256", alg: "ES512", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kid+"/"+tt.alg, func(t *testing.T) {
+			key := set[tt.kid]
+			if err := key.Verifies(tt.alg); (err != nil) != tt.wantErr {
+				t.Errorf("Verifies(%s) error = %v, wantErr %v", tt.alg, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	encryption := rsaJWK(t, "enc", "")
+	encryption.Use = "enc"
+
+	keys, err := Parse(encodeSet(t,
+		rsaJWK(t, "rsa", ""),
+		encryption,
+		jsonWebKey{Kty: "oct", Kid: "hmac"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("got %d keys, want only the RSA signing key", len(keys))
+	}
+	if _, ok := keys["rsa"]; !ok {
+		t.Error("rsa key is missing")
+	}
+
+	invalid := map[string][]byte{
+		"not json":       []byte("{"),
+		"bad curve":      encodeSet(t, jsonWebKey{Kty: "EC", Kid: "x", Crv: "P-192", X: "AQ", Y: "AQ"}),
+		"off curve":      encodeSet(t, jsonWebKey{Kty: "EC", Kid: "x", Crv: "P-256", X: "AQ", Y: "AQ"}),
+		"missing n":      encodeSet(t, jsonWebKey{Kty: "RSA", Kid: "x", E: "AQAB"}),
+		"small exponent": encodeSet(t, jsonWebKey{Kty: "RSA", Kid: "x", N: "AQAB", E: "AQ"}),
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(data); err == nil {
+				t.Error("Parse succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"property-managment-service/pkg/httpErrors"
+	"strconv"
+	"strings"
+)
+
+const (
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+	HeaderETag        = "ETag"
+)
+
+// ETag - сильный тег по версии ресурса
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch разбирает If-Match изменяющего запроса. nil означает "*" - подойдёт любая версия.
+// Без заголовка возвращает 428: изменения без версии затирали бы чужие правки.
+func IfMatch(c echo.Context) ([]int64, error) {
+	header := c.Request().Header.Get(HeaderIfMatch)
+	if header == "" {
+		return nil, httpErrors.NewRestErrorFrom(http.StatusPreconditionRequired, httpErrors.PreconditionRequired,
+			"send the ETag of the resource in If-Match")
+	}
+
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, nil
+		}
+		// Слабые теги при If-Match не совпадают ни с чем (RFC 9110, сильное сравнение)
+		version, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 64)
+		if err != nil || strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return nil, httpErrors.NewRestErrorFrom(http.StatusPreconditionFailed, httpErrors.PreconditionFailed, nil)
+	}
+	return versions, nil
+}
+
+// NotModified ставит ETag ответа и сообщает, совпал ли он с If-None-Match (слабое сравнение)
+func NotModified(c echo.Context, version int64) bool {
+	etag := ETag(version)
+	c.Response().Header().Set(HeaderETag, etag)
+
+	header := c.Request().Header.Get(HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckVersion сверяет текущую версию ресурса с результатом IfMatch и возвращает 412 при расхождении
+func CheckVersion(ifMatch []int64, version int64) error {
+	if ifMatch == nil {
+		return nil
+	}
+	for _, v := range ifMatch {
+		if v == version {
+			return nil
+		}
+	}
+	return httpErrors.NewRestErrorFrom(http.StatusPreconditionFailed, httpErrors.PreconditionFailed, nil)
+}
@@ -5,12 +5,18 @@ import (
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"log/slog"
+	"net/http"
+	"property-managment-service/pkg/httpErrors"
 )
 
 type ReqIDCtxKey struct{}
 
+// GetRequestID возвращает id запроса клиента или сгенерированный middleware RequestID
 func GetRequestID(c echo.Context) string {
-	return c.Request().Header.Get(echo.HeaderXRequestID)
+	if id := c.Request().Header.Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Response().Header().Get(echo.HeaderXRequestID)
 }
 
 func GetIPAddress(c echo.Context) string {
@@ -36,3 +42,30 @@ func LogResponseError(ctx echo.Context, log *slog.Logger, err error) {
 		err,
 	))
 }
+
+// NewHTTPErrorHandler - единая точка ответа с ошибкой: обработчики и middleware возвращают error,
+// а клиент всегда получает httpErrors.Body. Причины ошибок 5xx пишутся только в лог.
+func NewHTTPErrorHandler(log *slog.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		restErr := httpErrors.ParseErrors(err)
+		if restErr.Status() >= http.StatusInternalServerError {
+			LogResponseError(c, log, err)
+		} else {
+			log.Info(fmt.Sprintf("ClientError, RequestID: %s, IPAddress: %s, Error: %s",
+				GetRequestID(c), GetIPAddress(c), err))
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(restErr.Status())
+		} else {
+			err = c.JSON(restErr.Status(), httpErrors.NewBody(restErr, GetRequestID(c)))
+		}
+		if err != nil {
+			log.Error(fmt.Sprintf("failed to write error response, RequestID: %s, Error: %s", GetRequestID(c), err))
+		}
+	}
+}
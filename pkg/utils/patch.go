@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"net/http"
+	"property-managment-service/pkg/httpErrors"
+	"reflect"
+	"strings"
+)
+
+const MIMEMergePatch = "application/merge-patch+json"
+
+// MergePatch - разобранный JSON Merge Patch (RFC 7386) плоского ресурса
+type MergePatch struct {
+	fields map[string]bool
+	// Columns - новые значения колонок по тегу db; nil означает NULL
+	Columns map[string]interface{}
+}
+
+// Has сообщает, передано ли поле (в том числе со значением null)
+func (p *MergePatch) Has(field string) bool {
+	return p.fields[field]
+}
+
+func (p *MergePatch) Empty() bool {
+	return len(p.fields) == 0
+}
+
+// ReadMergePatch читает тело запроса в patch - указатель на структуру с полями-указателями,
+// у каждого поля которой есть теги json и db. Поле, которого нет в запросе, не меняется,
+// null очищает колонку. Проверяются только переданные поля; поля, которых нет в patch, отклоняются.
+func ReadMergePatch(c echo.Context, patch interface{}) (*MergePatch, error) {
+	contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if contentType != MIMEMergePatch && contentType != echo.MIMEApplicationJSON {
+		return nil, httpErrors.NewRestError(http.StatusUnsupportedMediaType,
+			"Content-Type must be "+MIMEMergePatch, nil)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, httpErrors.NewBadRequestError("merge patch must be a JSON object")
+	}
+
+	type field struct {
+		name   string
+		column string
+		value  reflect.Value
+	}
+	target := reflect.ValueOf(patch).Elem()
+	known := map[string]field{}
+	for i := 0; i < target.NumField(); i++ {
+		sf := target.Type().Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		known[name] = field{name: sf.Name, column: sf.Tag.Get("db"), value: target.Field(i)}
+	}
+
+	var unknown []httpErrors.FieldError
+	for name := range raw {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, httpErrors.FieldError{Field: name, Message: "cannot be changed"})
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, httpErrors.NewValidationError(unknown, nil)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(patch); err != nil {
+		return nil, httpErrors.NewBadRequestError("invalid merge patch: " + err.Error())
+	}
+
+	result := &MergePatch{fields: map[string]bool{}, Columns: map[string]interface{}{}}
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		f := known[name]
+		result.fields[name] = true
+		names = append(names, f.name)
+		if f.value.IsNil() {
+			result.Columns[f.column] = nil
+		} else {
+			result.Columns[f.column] = f.value.Elem().Interface()
+		}
+	}
+
+	if len(names) > 0 {
+		if err := validate.StructPartialCtx(c.Request().Context(), patch, names...); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
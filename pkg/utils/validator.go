@@ -3,12 +3,25 @@ package utils
 import (
 	"context"
 	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
 )
 
 var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	// В ошибках проверки поля называются так же, как в JSON запроса
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
 }
 
 func ValidateStruct(ctx context.Context, s interface{}) error {